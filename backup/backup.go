@@ -0,0 +1,441 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	config "noted/config"
+	"noted/logging"
+	"noted/storage"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix is the directory inside the storage directory snapshots are kept in
+const Prefix = "backups"
+
+// Periodic is the reason of snapshots taken every backup.interval
+const Periodic = "periodic"
+
+var ErrUnknownSnapshot = errors.New("unknown snapshot")
+
+// skipped are the directories of the storage directory that are not notes
+var skipped = map[string]bool{Prefix: true, ".git": true}
+
+// snapshotName is a snapshot's file name: when it was taken, why, and a
+// counter for snapshots taken in the same second
+var snapshotName = regexp.MustCompile(`^\d{8}-\d{6}-([a-z]+)(-\d+)?\.tar\.gz$`)
+
+type Snapshot struct {
+	// Name is the file name without the extension
+	Name   string
+	Reason string
+	Time   time.Time
+	Size   int64
+}
+
+func (s Snapshot) Path() string {
+	return path.Join(Directory(), s.Name+".tar.gz")
+}
+
+func storageDir() string {
+	return viper.GetString(config.ConfigStorageDir)
+}
+
+func Directory() string {
+	return path.Join(storageDir(), Prefix)
+}
+
+// Create archives the storage directory into a gzipped tarball named after
+// the time and reason, then removes the snapshots for the same reason beyond
+// backup.keep. Files are archived as they are on disk, so an encrypted store
+// stays encrypted in its snapshots.
+func Create(reason string) (Snapshot, error) {
+	if err := os.MkdirAll(Directory(), 0700); err != nil {
+		return Snapshot{}, err
+	}
+
+	stamp := time.Now().Format("20060102-150405")
+	name := path.Join(Directory(), fmt.Sprintf("%s-%s.tar.gz", stamp, reason))
+	output, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	// two snapshots in the same second
+	for i := 2; errors.Is(err, os.ErrExist); i++ {
+		name = path.Join(Directory(), fmt.Sprintf("%s-%s-%d.tar.gz", stamp, reason, i))
+		output, err = os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if err = archive(output); err != nil {
+		output.Close()
+		os.Remove(name)
+		return Snapshot{}, err
+	}
+	if err = output.Close(); err != nil {
+		os.Remove(name)
+		return Snapshot{}, err
+	}
+
+	snapshot, err := stat(path.Base(name))
+	if err != nil {
+		return snapshot, err
+	}
+	return snapshot, prune(reason)
+}
+
+// AutoSnapshot is a storage.ChangeHook taking a snapshot when the last
+// periodic one is older than backup.interval
+func AutoSnapshot(description string) {
+	interval := viper.GetDuration(config.ConfigBackupInterval)
+	if interval <= 0 {
+		return
+	}
+
+	snapshots, err := List()
+	if err != nil {
+		logging.Logger.Warn("failed to list snapshots", zap.Error(err))
+		return
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Reason == Periodic && time.Since(snapshot.Time) < interval {
+			return
+		}
+	}
+
+	if _, err = Create(Periodic); err != nil {
+		logging.Logger.Warn("failed to take a periodic snapshot", zap.Error(err))
+	}
+}
+
+// List returns the snapshots, newest first
+func List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(Directory())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !snapshotName.MatchString(entry.Name()) {
+			continue
+		}
+		snapshot, err := stat(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	slices.SortFunc(snapshots, func(a Snapshot, b Snapshot) int {
+		return b.Time.Compare(a.Time)
+	})
+	return snapshots, nil
+}
+
+// Find looks a snapshot up by its name or a unique prefix of it
+func Find(name string) (Snapshot, error) {
+	name = strings.TrimSuffix(path.Base(name), ".tar.gz")
+	snapshots, err := List()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var matched []Snapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot, nil
+		}
+		if strings.HasPrefix(snapshot.Name, name) {
+			matched = append(matched, snapshot)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("%w: %s", ErrUnknownSnapshot, name)
+	case 1:
+		return matched[0], nil
+	default:
+		return Snapshot{}, fmt.Errorf("%s matches %d snapshots", name, len(matched))
+	}
+}
+
+// Files lists the files in a snapshot, relative to the storage directory
+func Files(snapshot Snapshot) ([]string, error) {
+	contents, err := read(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(contents))
+	for name := range contents {
+		files = append(files, name)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// Restore puts files of a snapshot back, given relative to the storage
+// directory. Without files the whole store is brought back to the snapshot,
+// removing the files it did not have. A snapshot of the current state is
+// taken first, so a restore can be undone. It returns the files restored.
+func Restore(snapshot Snapshot, files []string) ([]string, error) {
+	contents, err := read(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	restore := make([]string, 0, len(files))
+	for _, file := range files {
+		name, err := relative(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := contents[name]; !ok {
+			return nil, fmt.Errorf("%s is not in snapshot %s", name, snapshot.Name)
+		}
+		restore = append(restore, name)
+	}
+	if len(files) == 0 {
+		for name := range contents {
+			restore = append(restore, name)
+		}
+	}
+	slices.Sort(restore)
+
+	if _, err = Create("restore"); err != nil {
+		return nil, fmt.Errorf("failed to snapshot the store before restoring: %w", err)
+	}
+
+	if len(files) == 0 {
+		current, err := walk()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range current {
+			if _, ok := contents[name]; !ok {
+				if err = os.Remove(path.Join(storageDir(), name)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, name := range restore {
+		file := path.Join(storageDir(), name)
+		if err = os.MkdirAll(path.Dir(file), 0755); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(file, contents[name].data, contents[name].mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(files) == 0 {
+		storage.Changed(fmt.Sprintf("restore snapshot %s", snapshot.Name))
+	} else {
+		storage.Changed(fmt.Sprintf("restore %s from snapshot %s", strings.Join(restore, ", "), snapshot.Name))
+	}
+	return restore, nil
+}
+
+// relative turns a file given on the command line, relative to the storage
+// directory or absolute, into a name in a snapshot
+func relative(file string) (string, error) {
+	if filepath.IsAbs(file) {
+		var err error
+		if file, err = filepath.Rel(storageDir(), file); err != nil {
+			return "", err
+		}
+	}
+	if !filepath.IsLocal(file) {
+		return "", fmt.Errorf("%s is not inside the storage directory", file)
+	}
+	return filepath.ToSlash(filepath.Clean(file)), nil
+}
+
+func stat(name string) (Snapshot, error) {
+	info, err := os.Stat(path.Join(Directory(), name))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{
+		Name: strings.TrimSuffix(name, ".tar.gz"),
+		Time: info.ModTime(),
+		Size: info.Size(),
+	}
+	if match := snapshotName.FindStringSubmatch(name); match != nil {
+		snapshot.Reason = match[1]
+	}
+	return snapshot, nil
+}
+
+// prune removes the oldest snapshots for reason beyond backup.keep. Each
+// reason is kept separately, so a run of deletions does not push out the
+// periodic snapshots.
+func prune(reason string) error {
+	snapshots, err := List()
+	if err != nil {
+		return err
+	}
+	keep := max(viper.GetInt(config.ConfigBackupKeep), 1)
+	for _, snapshot := range snapshots {
+		if snapshot.Reason != reason {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		if err = os.Remove(snapshot.Path()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logFile is the log file in use relative to the storage directory, empty
+// when it is kept elsewhere
+func logFile() string {
+	if logging.File() == "" {
+		return ""
+	}
+	relative, err := filepath.Rel(storageDir(), logging.File())
+	if err != nil || !filepath.IsLocal(relative) {
+		return ""
+	}
+	return filepath.ToSlash(relative)
+}
+
+// isLog reports whether name is the log file or one of its rotated copies,
+// which are named after it with a number appended
+func isLog(name string, log string) bool {
+	if log == "" {
+		return false
+	}
+	number, rotated := strings.CutPrefix(name, log+".")
+	if !rotated {
+		return name == log
+	}
+	_, err := strconv.Atoi(number)
+	return err == nil
+}
+
+// walk lists the files of the store, relative to the storage directory,
+// leaving out the snapshots and the log
+func walk() ([]string, error) {
+	log := logFile()
+	files := make([]string, 0)
+	err := filepath.WalkDir(storageDir(), func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(storageDir(), name)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && skipped[relative] {
+			return filepath.SkipDir
+		}
+		if entry.Type().IsRegular() && !isLog(filepath.ToSlash(relative), log) {
+			files = append(files, filepath.ToSlash(relative))
+		}
+		return nil
+	})
+	return files, err
+}
+
+func archive(output io.Writer) error {
+	files, err := walk()
+	if err != nil {
+		return err
+	}
+
+	compressed := gzip.NewWriter(output)
+	archived := tar.NewWriter(compressed)
+	for _, name := range files {
+		if err = add(archived, name); err != nil {
+			return err
+		}
+	}
+	if err = archived.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+func add(archived *tar.Writer, name string) error {
+	file, err := os.Open(path.Join(storageDir(), name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err = archived.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(archived, file)
+	return err
+}
+
+type archivedFile struct {
+	data []byte
+	mode fs.FileMode
+}
+
+// read loads the files of a snapshot, refusing names that would land
+// outside the storage directory
+func read(snapshot Snapshot) (map[string]archivedFile, error) {
+	input, err := os.Open(snapshot.Path())
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	compressed, err := gzip.NewReader(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", snapshot.Name, err)
+	}
+	archived := tar.NewReader(compressed)
+
+	contents := make(map[string]archivedFile)
+	for {
+		header, err := archived.Next()
+		if errors.Is(err, io.EOF) {
+			return contents, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", snapshot.Name, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(header.Name) {
+			return nil, fmt.Errorf("%s: %s is outside the storage directory", snapshot.Name, header.Name)
+		}
+		data, err := io.ReadAll(archived)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", snapshot.Name, err)
+		}
+		contents[header.Name] = archivedFile{data: data, mode: header.FileInfo().Mode().Perm()}
+	}
+}
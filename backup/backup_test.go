@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+	config "noted/config"
+	"noted/logging"
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+// newStore points noted at a temporary directory logging to a file of its
+// own inside it, as log.file can
+func newStore(t *testing.T) string {
+	t.Helper()
+	store := t.TempDir()
+	viper.Set(config.ConfigStorageDir, store)
+	viper.Set(config.ConfigBackupKeep, 10)
+
+	options := logging.Options{TerminalLevel: zapcore.WarnLevel, File: path.Join(store, "var", "noted.log"), MaxSize: 1}
+	if err := logging.Configure(options); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logging.Configure(logging.Options{}) })
+	return store
+}
+
+func write(t *testing.T, store string, name string, contents string) {
+	t.Helper()
+	file := path.Join(store, name)
+	if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func contents(t *testing.T, store string, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(path.Join(store, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "missing"
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRestoreStore(t *testing.T) {
+	store := newStore(t)
+	write(t, store, "task/2023-September.yaml", "september")
+	write(t, store, "journal/2023-September.md", "journal")
+	write(t, store, "var/noted.log.1", "rotated log")
+	logging.Logger.Info("before the snapshot")
+
+	snapshot, err := Create("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Files(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"journal/2023-September.md", "task/2023-September.yaml"}; !slices.Equal(files, want) {
+		t.Fatalf("snapshot holds %v, want %v without the log", files, want)
+	}
+
+	write(t, store, "task/2023-September.yaml", "september changed")
+	write(t, store, "task/2023-October.yaml", "october")
+	if err = os.Remove(path.Join(store, "journal/2023-September.md")); err != nil {
+		t.Fatal(err)
+	}
+	logging.Logger.Info("after the snapshot")
+
+	if _, err = Restore(snapshot, nil); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"task/2023-September.yaml":  "september",
+		"journal/2023-September.md": "journal",
+		"task/2023-October.yaml":    "missing",
+		"var/noted.log.1":           "rotated log",
+	} {
+		if got := contents(t, store, name); got != want {
+			t.Errorf("%s is %q after restoring, want %q", name, got, want)
+		}
+	}
+	if contents(t, store, "var/noted.log") == "missing" {
+		t.Error("restoring removed the log")
+	}
+
+	// the state before restoring was kept, so the restore can be undone
+	snapshots, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	undo := slices.IndexFunc(snapshots, func(s Snapshot) bool { return s.Reason == "restore" })
+	if undo < 0 {
+		t.Fatalf("snapshots are %+v, want one taken before restoring", snapshots)
+	}
+	if files, err = Files(snapshots[undo]); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(files, "task/2023-October.yaml") {
+		t.Errorf("snapshot before restoring holds %v, want the file the restore removed", files)
+	}
+}
+
+func TestRestoreFiles(t *testing.T) {
+	store := newStore(t)
+	write(t, store, "task/2023-September.yaml", "september")
+	write(t, store, "journal/2023-September.md", "journal")
+	snapshot, err := Create("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, store, "task/2023-September.yaml", "september changed")
+	write(t, store, "journal/2023-September.md", "journal changed")
+	write(t, store, "task/2023-October.yaml", "october")
+
+	restored, err := Restore(snapshot, []string{path.Join(store, "task/2023-September.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"task/2023-September.yaml"}; !slices.Equal(restored, want) {
+		t.Errorf("restored %v, want %v", restored, want)
+	}
+	for name, want := range map[string]string{
+		"task/2023-September.yaml":  "september",
+		"journal/2023-September.md": "journal changed",
+		"task/2023-October.yaml":    "october",
+	} {
+		if got := contents(t, store, name); got != want {
+			t.Errorf("%s is %q after restoring one file, want %q", name, got, want)
+		}
+	}
+
+	for _, file := range []string{"task/2023-October.yaml", "../elsewhere.yaml"} {
+		if _, err = Restore(snapshot, []string{file}); err == nil {
+			t.Errorf("restoring %s succeeded, want an error", file)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+	"github.com/emersion/go-webdav/caldav"
+	"github.com/google/uuid"
+	"net/http"
+	"noted/task"
+	"path"
+	"strings"
+)
+
+// backend publishes every task as a VTODO in a single calendar:
+//
+//	{prefix}/noted/                      principal
+//	{prefix}/noted/calendars/            calendar home set
+//	{prefix}/noted/calendars/tasks/      the task calendar
+//	{prefix}/noted/calendars/tasks/{id}.ics
+type backend struct {
+	prefix string
+}
+
+func NewCalDAVHandler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return &caldav.Handler{
+		Backend: backend{prefix: prefix},
+		Prefix:  prefix,
+	}
+}
+
+func (b backend) CurrentUserPrincipal(ctx context.Context) (string, error) {
+	return b.prefix + "/noted/", nil
+}
+
+func (b backend) CalendarHomeSetPath(ctx context.Context) (string, error) {
+	return b.prefix + "/noted/calendars/", nil
+}
+
+func (b backend) calendarPath() string {
+	return b.prefix + "/noted/calendars/tasks/"
+}
+
+func (b backend) objectPath(id string) string {
+	return b.calendarPath() + id + ".ics"
+}
+
+func (b backend) calendar() caldav.Calendar {
+	return caldav.Calendar{
+		Path:                  b.calendarPath(),
+		Name:                  "Tasks",
+		Description:           "note.d tasks",
+		SupportedComponentSet: []string{ical.CompToDo},
+	}
+}
+
+func (b backend) ListCalendars(ctx context.Context) ([]caldav.Calendar, error) {
+	return []caldav.Calendar{b.calendar()}, nil
+}
+
+func (b backend) GetCalendar(ctx context.Context, calendarPath string) (*caldav.Calendar, error) {
+	if strings.TrimSuffix(calendarPath, "/") != strings.TrimSuffix(b.calendarPath(), "/") {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("no calendar at %s", calendarPath))
+	}
+	cal := b.calendar()
+	return &cal, nil
+}
+
+func (b backend) object(t task.Task) (caldav.CalendarObject, error) {
+	cal := NewCalendar()
+	cal.Children = append(cal.Children, ToDo(t))
+
+	var encoded bytes.Buffer
+	if err := ical.NewEncoder(&encoded).Encode(cal); err != nil {
+		return caldav.CalendarObject{}, err
+	}
+	sum := sha256.Sum256(encoded.Bytes())
+
+	return caldav.CalendarObject{
+		Path:          b.objectPath(t.Id),
+		ModTime:       lastModified(t),
+		ContentLength: int64(encoded.Len()),
+		ETag:          hex.EncodeToString(sum[:16]),
+		Data:          cal,
+	}, nil
+}
+
+func (b backend) lookupTask(objectPath string) (task.Task, bool, error) {
+	id := strings.TrimSuffix(path.Base(objectPath), ".ics")
+	t, err := task.FindTask(id)
+	var notFound task.NotFoundError
+	var ambiguous task.AmbiguousIdError
+	// only the full id names a task, not a prefix of one
+	if errors.As(err, &notFound) || errors.As(err, &ambiguous) || (err == nil && t.Id != id) {
+		return t, false, nil
+	}
+	return t, err == nil, err
+}
+
+// lookupUID finds the task a client knows by uid, for clients that keep
+// putting a new object at the path they chose rather than the one returned
+func lookupUID(uid string) (task.Task, bool, error) {
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		return task.Task{}, false, err
+	}
+	for _, t := range tasks {
+		if UID(t) == uid {
+			return t, true, nil
+		}
+	}
+	return task.Task{}, false, nil
+}
+
+func (b backend) findTask(objectPath string) (task.Task, error) {
+	t, found, err := b.lookupTask(objectPath)
+	if err == nil && !found {
+		return t, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("no task at %s", objectPath))
+	}
+	return t, err
+}
+
+func (b backend) GetCalendarObject(ctx context.Context, objectPath string, req *caldav.CalendarCompRequest) (*caldav.CalendarObject, error) {
+	t, err := b.findTask(objectPath)
+	if err != nil {
+		return nil, err
+	}
+	object, err := b.object(t)
+	return &object, err
+}
+
+func (b backend) ListCalendarObjects(ctx context.Context, calendarPath string, req *caldav.CalendarCompRequest) ([]caldav.CalendarObject, error) {
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]caldav.CalendarObject, 0)
+	for _, t := range tasks {
+		object, err := b.object(t)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func (b backend) QueryCalendarObjects(ctx context.Context, query *caldav.CalendarQuery) ([]caldav.CalendarObject, error) {
+	objects, err := b.ListCalendarObjects(ctx, b.calendarPath(), &query.CompRequest)
+	if err != nil {
+		return nil, err
+	}
+	return caldav.Filter(query, objects)
+}
+
+// PutCalendarObject applies changes made by a client, most commonly a changed
+// STATUS. The task is looked up by the object's path, then by its UID; unknown
+// objects become new tasks, named after the path when it is a uuid so the
+// client finds them where it put them.
+func (b backend) PutCalendarObject(ctx context.Context, objectPath string, cal *ical.Calendar, opts *caldav.PutCalendarObjectOptions) (string, error) {
+	var todo *ical.Component
+	for _, child := range cal.Children {
+		if child.Name == ical.CompToDo {
+			todo = child
+			break
+		}
+	}
+	if todo == nil {
+		return "", webdav.NewHTTPError(http.StatusUnsupportedMediaType, errors.New("only VTODO components are supported"))
+	}
+	uid, err := todo.Props.Text(ical.PropUID)
+	if err != nil {
+		return "", webdav.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	t, found, err := b.lookupTask(objectPath)
+	if err == nil && !found && uid != "" {
+		t, found, err = lookupUID(uid)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err = b.checkPreconditions(t, found, opts); err != nil {
+		return "", err
+	}
+
+	if !found {
+		t = task.Task{Status: task.ToDo}
+		if id := strings.TrimSuffix(path.Base(objectPath), ".ics"); isUUID(id) {
+			t.Id = id
+		}
+		if uid != t.Id {
+			t.UID = uid
+		}
+	}
+
+	if err = ApplyToDo(todo, &t); err != nil {
+		return "", webdav.NewHTTPError(http.StatusBadRequest, err)
+	}
+	if found {
+		err = task.UpdateTask(t)
+	} else {
+		t, err = task.AddTask(t)
+	}
+	if err != nil {
+		return "", err
+	}
+	return b.objectPath(t.Id), nil
+}
+
+// isUUID accepts the plain form of uuids only, as Parse also takes urn: and
+// braced forms that make poor task ids
+func isUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil && len(id) == 36
+}
+
+// checkPreconditions honours If-None-Match, used by clients creating an
+// object, and If-Match, used to only overwrite the version they have seen
+func (b backend) checkPreconditions(t task.Task, found bool, opts *caldav.PutCalendarObjectOptions) error {
+	if opts == nil || (!opts.IfNoneMatch.IsSet() && !opts.IfMatch.IsSet()) {
+		return nil
+	}
+
+	var current string
+	if found {
+		object, err := b.object(t)
+		if err != nil {
+			return err
+		}
+		current = object.ETag
+	}
+	matches := func(condition webdav.ConditionalMatch) (bool, error) {
+		if condition.IsWildcard() {
+			return found, nil
+		}
+		etag, err := condition.ETag()
+		if err != nil {
+			return false, webdav.NewHTTPError(http.StatusBadRequest, err)
+		}
+		return found && etag == current, nil
+	}
+
+	if opts.IfNoneMatch.IsSet() {
+		if matched, err := matches(opts.IfNoneMatch); err != nil {
+			return err
+		} else if matched {
+			return webdav.NewHTTPError(http.StatusPreconditionFailed, errors.New("the object already exists"))
+		}
+	}
+	if opts.IfMatch.IsSet() {
+		if matched, err := matches(opts.IfMatch); err != nil {
+			return err
+		} else if !matched {
+			return webdav.NewHTTPError(http.StatusPreconditionFailed, errors.New("the object changed or does not exist"))
+		}
+	}
+	return nil
+}
+
+func (b backend) DeleteCalendarObject(ctx context.Context, objectPath string) error {
+	t, err := b.findTask(objectPath)
+	if err != nil {
+		return err
+	}
+	return task.DeleteTask(t)
+}
@@ -0,0 +1,188 @@
+package calendar
+
+import (
+	"context"
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav/caldav"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	config "noted/config"
+	"noted/storage"
+	"noted/task"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const prefix = "/caldav"
+
+// changes counts the changes announced, each a commit or snapshot when
+// syncing or backing up
+var changes int
+
+func init() {
+	storage.OnChange(func(string) { changes++ })
+}
+
+// client sends the requests of a caldav.Client with extra headers, such as
+// If-Match, which it has no options for, and records the last status
+type client struct {
+	http.Client
+	header http.Header
+	status int
+}
+
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	response, err := c.Client.Do(req)
+	if err == nil {
+		c.status = response.StatusCode
+	}
+	return response, err
+}
+
+// newClient serves an empty store in a temporary directory over CalDAV
+func newClient(t *testing.T) (*caldav.Client, *client) {
+	t.Helper()
+	store := t.TempDir()
+	if err := os.MkdirAll(path.Join(store, "task"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set(config.ConfigStorageDir, store)
+	viper.Set(config.ConfigTaskPrefix, "task")
+
+	server := httptest.NewServer(NewCalDAVHandler(prefix))
+	t.Cleanup(server.Close)
+
+	transport := &client{header: make(http.Header)}
+	caldavClient, err := caldav.NewClient(transport, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return caldavClient, transport
+}
+
+func objectPath(name string) string {
+	return prefix + "/noted/calendars/tasks/" + name + ".ics"
+}
+
+func todo(uid string, summary string, status string) *ical.Calendar {
+	cal := NewCalendar()
+	component := ical.NewComponent(ical.CompToDo)
+	component.Props.SetText(ical.PropUID, uid)
+	component.Props.SetDateTime(ical.PropDateTimeStamp, time.Now().UTC())
+	component.Props.SetText(ical.PropSummary, summary)
+	component.Props.SetText(ical.PropStatus, status)
+	cal.Children = append(cal.Children, component)
+	return cal
+}
+
+func listTasks(t *testing.T) []task.Task {
+	t.Helper()
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tasks
+}
+
+func TestPutKeepsPath(t *testing.T) {
+	c, _ := newClient(t)
+	ctx := context.Background()
+	id := "5b9e3c2a-8f41-4d6e-9a7b-1c2d3e4f5a6b"
+
+	changes = 0
+	if _, err := c.PutCalendarObject(ctx, objectPath(id), todo(id, "call mom", "NEEDS-ACTION")); err != nil {
+		t.Fatal(err)
+	}
+	if changes != 1 {
+		t.Errorf("putting a new object made %d changes, want 1", changes)
+	}
+	object, err := c.GetCalendarObject(ctx, objectPath(id))
+	if err != nil {
+		t.Fatalf("new object is not where it was put: %s", err)
+	}
+	if summary, _ := object.Data.Children[0].Props.Text(ical.PropSummary); summary != "call mom" {
+		t.Errorf("summary is %q, want %q", summary, "call mom")
+	}
+
+	if _, err = c.PutCalendarObject(ctx, objectPath(id), todo(id, "call mom", "COMPLETED")); err != nil {
+		t.Fatal(err)
+	}
+	tasks := listTasks(t)
+	if len(tasks) != 1 || tasks[0].Id != id || tasks[0].Status != task.Done {
+		t.Errorf("tasks are %+v, want the one task done", tasks)
+	}
+}
+
+func TestPutFindsUID(t *testing.T) {
+	c, _ := newClient(t)
+	ctx := context.Background()
+	uid := "20230901T090000Z-42@phone.example.com"
+
+	// the path is no uuid, so the task gets an id of its own
+	if _, err := c.PutCalendarObject(ctx, objectPath("42"), todo(uid, "water plants", "NEEDS-ACTION")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PutCalendarObject(ctx, objectPath("42"), todo(uid, "water the plants", "IN-PROCESS")); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := listTasks(t)
+	if len(tasks) != 1 {
+		t.Fatalf("putting the object twice made %d tasks", len(tasks))
+	}
+	if tasks[0].UID != uid || tasks[0].Task != "water the plants" || tasks[0].Status != task.InProgress {
+		t.Errorf("task is %+v, want the second version with the uid kept", tasks[0])
+	}
+	object, err := c.GetCalendarObject(ctx, objectPath(tasks[0].Id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := object.Data.Children[0].Props.Text(ical.PropUID); got != uid {
+		t.Errorf("UID is %q, want %q", got, uid)
+	}
+}
+
+func TestPutPreconditions(t *testing.T) {
+	c, transport := newClient(t)
+	ctx := context.Background()
+	id := "0d1e2f3a-4b5c-4d6e-8f70-8192a3b4c5d6"
+
+	transport.header.Set("If-None-Match", "*")
+	if _, err := c.PutCalendarObject(ctx, objectPath(id), todo(id, "pay rent", "NEEDS-ACTION")); err != nil {
+		t.Fatalf("creating with If-None-Match: %s", err)
+	}
+	if _, err := c.PutCalendarObject(ctx, objectPath(id), todo(id, "pay rent twice", "NEEDS-ACTION")); err == nil || transport.status != 412 {
+		t.Errorf("overwriting with If-None-Match returned %d, want 412", transport.status)
+	}
+	transport.header.Del("If-None-Match")
+
+	object, err := c.GetCalendarObject(ctx, objectPath(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport.header.Set("If-Match", strconv.Quote("0123456789abcdef"))
+	if _, err = c.PutCalendarObject(ctx, objectPath(id), todo(id, "pay rent", "COMPLETED")); err == nil || transport.status != 412 {
+		t.Errorf("overwriting a stale version returned %d, want 412", transport.status)
+	}
+	if _, err = c.PutCalendarObject(ctx, objectPath("6f5e4d3c-2b1a-4098-8765-43210fedcba9"), todo("other", "new", "NEEDS-ACTION")); err == nil || transport.status != 412 {
+		t.Errorf("If-Match on a missing object returned %d, want 412", transport.status)
+	}
+
+	transport.header.Set("If-Match", strconv.Quote(object.ETag))
+	if _, err = c.PutCalendarObject(ctx, objectPath(id), todo(id, "pay rent", "COMPLETED")); err != nil {
+		t.Fatalf("overwriting the current version: %s", err)
+	}
+
+	tasks := listTasks(t)
+	if len(tasks) != 1 || tasks[0].Task != "pay rent" || tasks[0].Status != task.Done {
+		t.Errorf("tasks are %+v, want the one task done", tasks)
+	}
+}
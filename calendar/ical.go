@@ -0,0 +1,148 @@
+package calendar
+
+import (
+	"github.com/emersion/go-ical"
+	"noted/task"
+	"strings"
+	"time"
+)
+
+const productId = "-//note.d//noted//EN"
+
+// statusProp round-trips the statuses iCalendar has no equivalent for
+const statusProp = "X-NOTED-STATUS"
+
+func NewCalendar() *ical.Calendar {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, productId)
+	return cal
+}
+
+// ToStatus maps a task status to a VTODO STATUS
+func ToStatus(status task.Status) string {
+	switch status {
+	case task.InProgress:
+		return "IN-PROCESS"
+	case task.Cancelled:
+		return "CANCELLED"
+	case task.Done:
+		return "COMPLETED"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// FromStatus maps a VTODO STATUS back to a task status, preferring the
+// original status when the component carries one that still agrees
+func FromStatus(value string, original string) task.Status {
+	if original != "" {
+		if status, err := task.ParseStatus(original); err == nil && ToStatus(status) == strings.ToUpper(value) {
+			return status
+		}
+	}
+
+	switch strings.ToUpper(value) {
+	case "IN-PROCESS":
+		return task.InProgress
+	case "CANCELLED":
+		return task.Cancelled
+	case "COMPLETED":
+		return task.Done
+	default:
+		return task.ToDo
+	}
+}
+
+func lastModified(t task.Task) time.Time {
+	modified := t.CreatedAt
+	for _, at := range t.Modified {
+		if at.After(modified) {
+			modified = at
+		}
+	}
+	return modified
+}
+
+// UID identifies a task in iCalendar data, keeping the UID of tasks imported
+// from another calendar so exporting them again does not create duplicates
+func UID(t task.Task) string {
+	if t.UID != "" {
+		return t.UID
+	}
+	return t.Id
+}
+
+// ToDo renders a task as a VTODO component
+func ToDo(t task.Task) *ical.Component {
+	todo := ical.NewComponent(ical.CompToDo)
+	todo.Props.SetText(ical.PropUID, UID(t))
+	// without a METHOD the stamp is the last modification, which also keeps
+	// the rendering stable for etags
+	todo.Props.SetDateTime(ical.PropDateTimeStamp, lastModified(t).UTC())
+	todo.Props.SetDateTime(ical.PropCreated, t.CreatedAt.UTC())
+	todo.Props.SetDateTime(ical.PropLastModified, lastModified(t).UTC())
+	todo.Props.SetText(ical.PropSummary, t.Task)
+	if t.Detail != "" {
+		todo.Props.SetText(ical.PropDescription, t.Detail)
+	}
+	if t.DueAt != nil {
+		todo.Props.SetDateTime(ical.PropDue, t.DueAt.UTC())
+	}
+	if t.ScheduledFor != nil {
+		todo.Props.SetDateTime(ical.PropDateTimeStart, t.ScheduledFor.UTC())
+	}
+	if t.CompletedAt != nil {
+		todo.Props.SetDateTime(ical.PropCompleted, t.CompletedAt.UTC())
+	}
+	todo.Props.SetText(ical.PropStatus, ToStatus(t.Status))
+	todo.Props.SetText(statusProp, t.Status.AsString())
+	return todo
+}
+
+// ApplyToDo copies the fields of a VTODO onto t
+func ApplyToDo(todo *ical.Component, t *task.Task) error {
+	if summary, err := todo.Props.Text(ical.PropSummary); err != nil {
+		return err
+	} else if summary != "" {
+		t.Task = summary
+	}
+
+	description, err := todo.Props.Text(ical.PropDescription)
+	if err != nil {
+		return err
+	}
+	t.Detail = description
+
+	if t.DueAt, err = optionalDateTime(todo, ical.PropDue); err != nil {
+		return err
+	}
+	if t.ScheduledFor, err = optionalDateTime(todo, ical.PropDateTimeStart); err != nil {
+		return err
+	}
+
+	status, err := todo.Props.Text(ical.PropStatus)
+	if err != nil {
+		return err
+	}
+	original, _ := todo.Props.Text(statusProp)
+	t.Status = FromStatus(status, original)
+
+	if completed, err := optionalDateTime(todo, ical.PropCompleted); err != nil {
+		return err
+	} else if completed != nil {
+		t.CompletedAt = completed
+	}
+	return nil
+}
+
+func optionalDateTime(component *ical.Component, name string) (*time.Time, error) {
+	if component.Props.Get(name) == nil {
+		return nil, nil
+	}
+	value, err := component.Props.DateTime(name, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
@@ -0,0 +1,149 @@
+package calendar
+
+import (
+	"fmt"
+	"github.com/emersion/go-ical"
+	"go.uber.org/zap"
+	"noted/logging"
+	"noted/task"
+	"time"
+)
+
+// scheduled tasks with a time of day are exported as events of this length
+const eventDuration = time.Hour
+
+// eventSuffix keeps the UID of a scheduled task's VEVENT distinct from its VTODO
+const eventSuffix = "-scheduled"
+
+// Export renders tasks as a calendar of VTODOs, with an additional VEVENT for
+// every scheduled task so it also shows up in plain calendar views
+func Export(tasks []task.Task) *ical.Calendar {
+	cal := NewCalendar()
+	for _, t := range tasks {
+		cal.Children = append(cal.Children, ToDo(t))
+	}
+	for _, t := range tasks {
+		if t.ScheduledFor != nil {
+			cal.Children = append(cal.Children, Event(t))
+		}
+	}
+	return cal
+}
+
+// Event renders a scheduled task as a VEVENT related to its VTODO. Tasks
+// scheduled at midnight become all-day events.
+func Event(t task.Task) *ical.Component {
+	event := ical.NewComponent(ical.CompEvent)
+	event.Props.SetText(ical.PropUID, UID(t)+eventSuffix)
+	event.Props.SetDateTime(ical.PropDateTimeStamp, lastModified(t).UTC())
+	event.Props.SetText(ical.PropRelatedTo, UID(t))
+	event.Props.SetText(ical.PropSummary, t.Task)
+	if t.Detail != "" {
+		event.Props.SetText(ical.PropDescription, t.Detail)
+	}
+
+	start, end := ical.NewProp(ical.PropDateTimeStart), ical.NewProp(ical.PropDateTimeEnd)
+	scheduled := t.ScheduledFor.In(time.Local)
+	if scheduled.Hour() == 0 && scheduled.Minute() == 0 && scheduled.Second() == 0 {
+		start.SetDate(scheduled)
+		end.SetDate(scheduled.AddDate(0, 0, 1))
+	} else {
+		start.SetDateTime(scheduled.UTC())
+		end.SetDateTime(scheduled.Add(eventDuration).UTC())
+	}
+	event.Props.Set(start)
+	event.Props.Set(end)
+
+	if t.Status == task.Cancelled {
+		event.Props.SetText(ical.PropStatus, "CANCELLED")
+	}
+	return event
+}
+
+// ApplyEvent copies the fields of a VEVENT onto t, scheduling it for the
+// start of the event
+func ApplyEvent(event *ical.Component, t *task.Task) error {
+	if summary, err := event.Props.Text(ical.PropSummary); err != nil {
+		return err
+	} else if summary != "" {
+		t.Task = summary
+	}
+
+	description, err := event.Props.Text(ical.PropDescription)
+	if err != nil {
+		return err
+	}
+	t.Detail = description
+
+	if t.ScheduledFor, err = optionalDateTime(event, ical.PropDateTimeStart); err != nil {
+		return err
+	}
+
+	status, err := event.Props.Text(ical.PropStatus)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == "CANCELLED":
+		t.Status = task.Cancelled
+	case t.Status == task.ToDo:
+		t.Status = task.Scheduled
+	}
+	return nil
+}
+
+// Import creates a task for every VTODO and VEVENT in cal. Components whose
+// UID matches a known task update that task instead, and events exported
+// alongside a task's VTODO are skipped.
+func Import(cal *ical.Calendar) (task.ImportResult, error) {
+	importer, err := task.NewImporter()
+	if err != nil {
+		return task.ImportResult{}, err
+	}
+
+	todos := make(map[string]bool)
+	for _, child := range cal.Children {
+		if child.Name == ical.CompToDo {
+			if uid, _ := child.Props.Text(ical.PropUID); uid != "" {
+				todos[uid] = true
+			}
+		}
+	}
+
+	for _, child := range cal.Children {
+		var apply func(*ical.Component, *task.Task) error
+		switch child.Name {
+		case ical.CompToDo:
+			apply = ApplyToDo
+		case ical.CompEvent:
+			if related, _ := child.Props.Text(ical.PropRelatedTo); related != "" && (todos[related] || importer.Known(related)) {
+				importer.Skip()
+				continue
+			}
+			apply = ApplyEvent
+		default:
+			continue
+		}
+
+		uid, err := child.Props.Text(ical.PropUID)
+		if err != nil {
+			return importer.Result, err
+		}
+
+		component := child
+		_, err = importer.Import(uid, func(t *task.Task) error {
+			if t.CreatedAt.IsZero() {
+				if created, err := optionalDateTime(component, ical.PropCreated); err == nil && created != nil {
+					t.CreatedAt = *created
+				}
+			}
+			return apply(component, t)
+		})
+		if err != nil {
+			return importer.Result, fmt.Errorf("failed to import %s %s: %w", child.Name, uid, err)
+		}
+		logging.Logger.Debug("imported component", zap.String("component", child.Name), zap.String("uid", uid))
+	}
+
+	return importer.Result, nil
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/backup"
+)
+
+func init() {
+	BackupCmd.AddCommand(backup.CreateBackupCmd)
+	BackupCmd.AddCommand(backup.ListBackupCmd)
+	BackupCmd.AddCommand(backup.RestoreBackupCmd)
+}
+
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "manage snapshots of the note store",
+	Long:  "Take snapshots of the note store and restore them, or single files from them",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/backup"
+)
+
+var CreateBackupCmd = &cobra.Command{
+	Use:   "create",
+	Short: "take a snapshot",
+	Long:  "archive the note store into a snapshot in the backups directory",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snapshot, err := backup.Create("manual")
+		if err != nil {
+			return err
+		}
+		fmt.Println(snapshot.Path())
+		return nil
+	},
+}
+
+var ListBackupCmd = &cobra.Command{
+	Use:   "list [snapshot]",
+	Short: "list snapshots or the files of one",
+	Long:  "list the snapshots, newest first, or the files in the given snapshot",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			snapshot, err := backup.Find(args[0])
+			if err != nil {
+				return err
+			}
+			files, err := backup.Files(snapshot)
+			if err != nil {
+				return err
+			}
+			for _, file := range files {
+				fmt.Println(file)
+			}
+			return nil
+		}
+
+		snapshots, err := backup.List()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("no snapshots yet")
+		}
+		for _, snapshot := range snapshots {
+			fmt.Printf("%-32s %s  %-8s %6.1f kB\n", snapshot.Name, snapshot.Time.Format("2006-01-02 15:04"), snapshot.Reason, float64(snapshot.Size)/1024)
+		}
+		return nil
+	},
+}
+
+var RestoreBackupCmd = &cobra.Command{
+	Use:   "restore <snapshot> [file...]",
+	Short: "restore a snapshot or files from it",
+	Long:  "bring the note store back to a snapshot, or only the given files such as task/2023-September.yaml. The current state is snapshotted first, so a restore can be undone.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snapshot, err := backup.Find(args[0])
+		if err != nil {
+			return err
+		}
+		restored, err := backup.Restore(snapshot, args[1:])
+		if err != nil {
+			return err
+		}
+		for _, file := range restored {
+			fmt.Printf("restored %s\n", file)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/config"
+)
+
+func init() {
+	ConfigCmd.AddCommand(config.ShowConfigCmd)
+	ConfigCmd.AddCommand(config.GetConfigCmd)
+	ConfigCmd.AddCommand(config.SetConfigCmd)
+	ConfigCmd.AddCommand(config.PathConfigCmd)
+	ConfigCmd.AddCommand(config.InitConfigCmd)
+}
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "inspect and change the configuration",
+	Long:  "Show, change and create the noted configuration file",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
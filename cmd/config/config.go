@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"noted/config"
+	"noted/workspace"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var force bool
+
+func init() {
+	InitConfigCmd.Flags().BoolVarP(&force, "force", "f", false, "overwrite an existing configuration file")
+}
+
+var workspaceKey = regexp.MustCompile(`^(?i)workspaces\.([a-z0-9][a-z0-9_-]*)\.storageDir$`)
+
+var ShowConfigCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the configuration",
+	Long:  "show every setting in effect and where it comes from: the configuration file, the environment, a flag or the default",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("# %s\n", noted.File())
+		for _, key := range noted.Keys {
+			value := viper.GetString(key)
+			if value == "" {
+				continue
+			}
+			fmt.Printf("%s: %s  # %s\n", key, value, source(cmd, key))
+		}
+
+		workspaces := workspace.List()[1:]
+		if len(workspaces) > 0 {
+			fmt.Printf("%s:\n", noted.ConfigWorkspaces)
+			for _, w := range workspaces {
+				fmt.Printf("  %s:\n    %s: %s\n", w.Name, noted.ConfigStorageDir, w.StorageDir)
+			}
+		}
+	},
+}
+
+var GetConfigCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "print a setting",
+	Long:  "print the value in effect for a setting, such as storageDir or workspaces.work.storageDir",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := canonicalKey(args[0])
+		if err != nil {
+			return err
+		}
+		if !viper.IsSet(key) {
+			// like git config, an unset key prints nothing
+			os.Exit(1)
+		}
+		fmt.Println(viper.GetString(key))
+		return nil
+	},
+}
+
+var SetConfigCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "change a setting",
+	Long:  "change a setting in the configuration file, keeping its comments",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := canonicalKey(args[0])
+		if err != nil {
+			return err
+		}
+		value := args[1]
+
+		if key == noted.ConfigCryptSessionTimeout {
+			if _, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("%w for %s: %w", noted.ErrInvalidValue, key, err)
+			}
+		}
+		if key == noted.ConfigWorkspace && value != workspace.Default {
+			if _, err = workspace.Get(value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key, err)
+			}
+		}
+
+		// a configuration that was already broken may take several fixes, so
+		// only refuse values that break a working one
+		before := validate()
+		viper.Set(key, value)
+		if after := validate(); after != nil && before == nil {
+			return fmt.Errorf("%w for %s:\n%w", noted.ErrInvalidValue, key, after)
+		} else if after != nil {
+			fmt.Fprintf(os.Stderr, "configuration is still invalid:\n%s\n", after)
+		}
+
+		if err = noted.WriteValue(noted.File(), key, value); err != nil {
+			return fmt.Errorf("failed to write configuration file: %w", err)
+		}
+		fmt.Printf("%s: %s\n", key, value)
+		return nil
+	},
+}
+
+var PathConfigCmd = &cobra.Command{
+	Use:   "path",
+	Short: "print the configuration file path",
+	Long:  "print the path of the configuration file noted reads, which may not exist yet",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(noted.File())
+		if _, err := os.Stat(noted.File()); errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "the file does not exist yet, create it with `noted config init`")
+		}
+	},
+}
+
+var InitConfigCmd = &cobra.Command{
+	Use:   "init",
+	Short: "write a default configuration file",
+	Long:  "write a configuration file with the default settings, each explained in a comment",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := noted.File()
+		if err := noted.Writable(file); err != nil {
+			return err
+		}
+		if _, err := os.Stat(file); err == nil && !force {
+			return fmt.Errorf("%w: %s, use --force to overwrite it", noted.ErrFileExists, file)
+		}
+		if err := os.WriteFile(file, []byte(noted.DefaultFile), 0644); err != nil {
+			return fmt.Errorf("failed to write configuration file: %w", err)
+		}
+		fmt.Printf("wrote %s\n", file)
+		return nil
+	},
+}
+
+// canonicalKey spells key the way the configuration file does, as viper
+// ignores case but the file should stay readable
+func canonicalKey(key string) (string, error) {
+	for _, known := range noted.Keys {
+		if strings.EqualFold(key, known) {
+			return known, nil
+		}
+	}
+	if match := workspaceKey.FindStringSubmatch(key); match != nil {
+		return fmt.Sprintf("%s.%s.%s", noted.ConfigWorkspaces, strings.ToLower(match[1]), noted.ConfigStorageDir), nil
+	}
+	return "", fmt.Errorf("%w %q, known settings are %s and workspaces.<name>.storageDir", noted.ErrUnknownSetting, key, strings.Join(noted.Keys, ", "))
+}
+
+func validate() error {
+	config, err := noted.Load()
+	if err != nil {
+		return err
+	}
+	if expanded, err := homedir.Expand(config.StorageDir); err == nil {
+		config.StorageDir = expanded
+	}
+	return config.Validate()
+}
+
+func source(cmd *cobra.Command, key string) string {
+	switch {
+	case key == noted.ConfigWorkspace && cmd.Flags().Changed("workspace"):
+		return "flag"
+	case key == noted.ConfigStorageDir && workspace.Current() != workspace.Default:
+		return "workspace " + workspace.Current()
+	}
+	if _, ok := os.LookupEnv(noted.EnvironmentVariable(key)); ok {
+		return noted.EnvironmentVariable(key)
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/crypt"
+)
+
+func init() {
+	CryptCmd.AddCommand(crypt.InitCryptCmd)
+	CryptCmd.AddCommand(crypt.LockCryptCmd)
+	CryptCmd.AddCommand(crypt.UnlockCryptCmd)
+	CryptCmd.AddCommand(crypt.RekeyCryptCmd)
+}
+
+var CryptCmd = &cobra.Command{
+	Use:   "crypt",
+	Short: "encrypt the note store",
+	Long:  "Encrypt tasks and journal entries at rest with a passphrase",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
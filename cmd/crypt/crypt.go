@@ -0,0 +1,142 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/backup"
+	"noted/crypt"
+	"noted/journal"
+	"noted/storage"
+	"noted/task"
+	"noted/trash"
+	"os"
+	"path"
+)
+
+var InitCryptCmd = &cobra.Command{
+	Use:   "init",
+	Short: "encrypt the note store",
+	Long:  "choose a passphrase and encrypt every task and journal file with it",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passphrase, err := newPassphrase()
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+
+		key, err := crypt.Init(passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to initialize encryption: %w", err)
+		}
+
+		if err = recodeStore(nil, key); err != nil {
+			return fmt.Errorf("failed to encrypt note store: %w", err)
+		}
+
+		storage.Changed("encrypt note store")
+		fmt.Println("note store encrypted")
+		return nil
+	},
+}
+
+var LockCryptCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "forget the cached key",
+	Long:  "remove the session key cache so the next command asks for the passphrase",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := crypt.Lock(); err != nil {
+			return fmt.Errorf("failed to lock note store: %w", err)
+		}
+		fmt.Println("note store locked")
+		return nil
+	},
+}
+
+var UnlockCryptCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "cache the key for this session",
+	Long:  "ask for the passphrase once and cache the key so following commands do not prompt",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passphrase, err := passphrase("passphrase: ")
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+
+		if err = crypt.Unlock(passphrase); err != nil {
+			return fmt.Errorf("failed to unlock note store: %w", err)
+		}
+		fmt.Println("note store unlocked")
+		return nil
+	},
+}
+
+var RekeyCryptCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "change the passphrase",
+	Long:  "change the passphrase and re-encrypt every task and journal file with the new key",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		current, err := passphrase("current passphrase: ")
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+
+		replacement, err := newPassphrase()
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+
+		if _, err = backup.Create("rekey"); err != nil {
+			return fmt.Errorf("failed to snapshot the store before re-encrypting it: %w", err)
+		}
+
+		if err = crypt.Rekey(current, replacement, recodeStore); err != nil {
+			return fmt.Errorf("failed to change passphrase: %w", err)
+		}
+
+		storage.Changed("change note store passphrase")
+		fmt.Println("passphrase changed")
+		return nil
+	},
+}
+
+func passphrase(label string) (string, error) {
+	if value, ok := os.LookupEnv("NOTED_PASSPHRASE"); ok {
+		return value, nil
+	}
+	return crypt.Prompt(label)
+}
+
+func newPassphrase() (string, error) {
+	if value, ok := os.LookupEnv("NOTED_NEW_PASSPHRASE"); ok {
+		return value, nil
+	}
+
+	first, err := crypt.Prompt("new passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if first == "" {
+		return "", errors.New("passphrase cannot be empty")
+	}
+
+	second, err := crypt.Prompt("repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if first != second {
+		return "", errors.New("passphrases do not match")
+	}
+	return first, nil
+}
+
+func recodeStore(from []byte, to []byte) error {
+	files, err := storage.Files(task.Directory(), journal.Directory(), path.Dir(trash.File()))
+	if err != nil {
+		return err
+	}
+	return storage.Recode(files, from, to)
+}
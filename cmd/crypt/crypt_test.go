@@ -0,0 +1,165 @@
+package crypt
+
+import (
+	"github.com/spf13/viper"
+	"io/fs"
+	"maps"
+	"noted/backup"
+	trashcmd "noted/cmd/trash"
+	config "noted/config"
+	"noted/crypt"
+	"noted/journal"
+	"noted/task"
+	"noted/trash"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStore points noted at an empty store in a temporary directory, keeping
+// the key in this process only
+func newStore(t *testing.T) string {
+	t.Helper()
+	store := t.TempDir()
+	for _, directory := range []string{"task", "journal"} {
+		if err := os.MkdirAll(path.Join(store, directory), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.Set(config.ConfigStorageDir, store)
+	viper.Set(config.ConfigTaskPrefix, "task")
+	viper.Set(config.ConfigJournalPrefix, "journal")
+	viper.Set(config.ConfigCryptSessionTimeout, 0)
+	viper.Set(config.ConfigBackupKeep, 10)
+	crypt.Forget()
+	t.Cleanup(crypt.Forget)
+	return store
+}
+
+// run runs a command the way the command line does, with passphrases from
+// the environment rather than the terminal
+func run(t *testing.T, command func() error, passphrase string, newPassphrase string) {
+	t.Helper()
+	t.Setenv("NOTED_PASSPHRASE", passphrase)
+	t.Setenv("NOTED_NEW_PASSPHRASE", newPassphrase)
+	if err := command(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func initStore(t *testing.T, passphrase string) {
+	run(t, func() error { return InitCryptCmd.RunE(InitCryptCmd, nil) }, "", passphrase)
+}
+
+func rekeyStore(t *testing.T, passphrase string, newPassphrase string) {
+	run(t, func() error { return RekeyCryptCmd.RunE(RekeyCryptCmd, nil) }, passphrase, newPassphrase)
+}
+
+func TestRekeyKeepsTrash(t *testing.T) {
+	newStore(t)
+	initStore(t, "old secret")
+
+	added, err := task.AddTask(task.Task{Task: "shred letters"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = task.DeleteTask(added); err != nil {
+		t.Fatal(err)
+	}
+	rekeyStore(t, "old secret", "new secret")
+
+	// as the next command would, with only the new passphrase at hand
+	crypt.Forget()
+	t.Setenv("NOTED_PASSPHRASE", "new secret")
+	if err = trashcmd.ListTrashCmd.RunE(trashcmd.ListTrashCmd, nil); err != nil {
+		t.Fatalf("trash list after rekey: %s", err)
+	}
+	items, err := trash.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Title != "shred letters" {
+		t.Errorf("trash holds %+v, want the deleted task", items)
+	}
+}
+
+// readStore reads every file of the store but the encryption header and the
+// snapshots, which archive the files as they were, decrypting them with key
+// when there is one
+func readStore(t *testing.T, store string, key []byte) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(store, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, _ := filepath.Rel(store, name)
+		if entry.IsDir() && relative == backup.Prefix {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() || relative == "crypt.yaml" {
+			return nil
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if key != nil {
+			if !crypt.IsEncrypted(data) {
+				t.Errorf("%s is not encrypted", relative)
+				return nil
+			}
+			if data, err = crypt.Decrypt(key, data); err != nil {
+				t.Errorf("%s: %s", relative, err)
+				return nil
+			}
+		}
+		files[relative] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestRecodeStore(t *testing.T) {
+	store := newStore(t)
+	if _, err := task.AddTask(task.Task{Task: "water plants"}); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := task.AddTask(task.Task{Task: "call plumber"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = task.DeleteTask(deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err = journal.SaveJournalEntry(time.Now(), "planted tulips"); err != nil {
+		t.Fatal(err)
+	}
+	plain := readStore(t, store, nil)
+	if len(plain) != 3 {
+		t.Fatalf("store holds %v, want a task, a journal and a trash file", plain)
+	}
+
+	initStore(t, "old secret")
+	key, err := crypt.Verify("old secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted := readStore(t, store, key); !maps.Equal(encrypted, plain) {
+		t.Errorf("store decrypts to %v after init, want %v", encrypted, plain)
+	}
+
+	rekeyStore(t, "old secret", "new secret")
+	if key, err = crypt.Verify("new secret"); err != nil {
+		t.Fatal(err)
+	}
+	if rekeyed := readStore(t, store, key); !maps.Equal(rekeyed, plain) {
+		t.Errorf("store decrypts to %v after rekey, want %v", rekeyed, plain)
+	}
+}
@@ -0,0 +1,128 @@
+package dashboard
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"math"
+	config "noted/config"
+	"noted/task"
+	"noted/watch"
+	"strings"
+	"time"
+)
+
+// agendaDays is how far ahead the agenda looks
+const agendaDays = 7
+
+var (
+	sectionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	mutedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	overdueStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+// agenda lists open tasks by when they need attention: overdue, today and
+// the coming week
+type agenda struct {
+	viewport viewport.Model
+}
+
+func newAgenda(tasks []task.Task) agenda {
+	a := agenda{viewport: viewport.New(0, 0)}
+	a.viewport.SetContent(renderAgenda(tasks, time.Now()))
+	return a
+}
+
+func (a agenda) Init() tea.Cmd {
+	return nil
+}
+
+func (a agenda) Update(msg tea.Msg) (agenda, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := config.DocStyle.GetFrameSize()
+		a.viewport.Width, a.viewport.Height = msg.Width-h, msg.Height-v
+	case watch.ChangedMsg:
+		tasks, err := task.ListTasks(false)
+		content := renderAgenda(tasks, time.Now())
+		if err != nil {
+			content = overdueStyle.Render(fmt.Sprintf("failed to reload: %s", err)) + "\n\n" + content
+		}
+		a.viewport.SetContent(content)
+	}
+
+	var cmd tea.Cmd
+	a.viewport, cmd = a.viewport.Update(msg)
+	return a, cmd
+}
+
+func (a agenda) View() string {
+	return config.DocStyle.Render(a.viewport.View())
+}
+
+func renderAgenda(tasks []task.Task, now time.Time) string {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	days := make([][]string, agendaDays+1)
+	overdue := make([]string, 0)
+	inProgress := make([]string, 0)
+
+	for _, t := range tasks {
+		if !t.Open() {
+			continue
+		}
+		if t.Status == task.InProgress {
+			inProgress = append(inProgress, t.Title())
+		}
+
+		when := t.DueAt
+		label := "due"
+		if when == nil || (t.ScheduledFor != nil && t.ScheduledFor.Before(*when)) {
+			when, label = t.ScheduledFor, "scheduled"
+		}
+		if when == nil {
+			continue
+		}
+
+		line := fmt.Sprintf("%s %s", t.Title(), mutedStyle.Render(fmt.Sprintf("(%s %s)", label, when.Format("15:04"))))
+		if t.Overdue(today) {
+			overdue = append(overdue, fmt.Sprintf("%s %s", t.Title(), overdueStyle.Render(fmt.Sprintf("(due %s)", t.DueAt.Format("Jan 2")))))
+			continue
+		}
+		local := when.In(time.Local)
+		// rounded as days around daylight saving changes are not 24 hours long
+		day := int(math.Round(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local).Sub(today).Hours() / 24))
+		if day >= 0 && day <= agendaDays {
+			days[day] = append(days[day], line)
+		}
+	}
+
+	var out strings.Builder
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		out.WriteString(sectionStyle.Render(title) + "\n")
+		for _, line := range lines {
+			out.WriteString("  • " + line + "\n")
+		}
+		out.WriteString("\n")
+	}
+
+	section("Overdue", overdue)
+	section("In progress", inProgress)
+	for i, lines := range days {
+		title := today.AddDate(0, 0, i).Format("Monday, January 2")
+		if i == 0 {
+			title = "Today"
+		} else if i == 1 {
+			title = "Tomorrow"
+		}
+		section(title, lines)
+	}
+
+	if out.Len() == 0 {
+		return mutedStyle.Render(fmt.Sprintf("nothing due in the next %d days", agendaDays))
+	}
+	return out.String()
+}
@@ -0,0 +1,274 @@
+// Package dashboard combines the task, journal, agenda and search views into
+// the program started by a bare `noted`
+package dashboard
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.uber.org/zap"
+	journalcmd "noted/cmd/journal"
+	taskcmd "noted/cmd/task"
+	"noted/journal"
+	"noted/logging"
+	"noted/task"
+	"noted/watch"
+	"strings"
+	"time"
+)
+
+type pane int
+
+const (
+	tasksPane pane = iota
+	journalPane
+	agendaPane
+	searchPane
+)
+
+var paneNames = []string{"Tasks", "Journal", "Agenda", "Search"}
+
+// the tab bar and its margin
+const tabBarHeight = 2
+
+var (
+	tabStyle       = lipgloss.NewStyle().Padding(0, 2).Foreground(lipgloss.Color("244"))
+	activeTabStyle = tabStyle.Copy().Bold(true).Foreground(lipgloss.Color("205")).Underline(true)
+	overlayStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("205")).Padding(1, 2)
+	statusStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).MarginLeft(2)
+)
+
+var (
+	nextPaneKeyBinding     = key.NewBinding(key.WithKeys("tab"))
+	previousPaneKeyBinding = key.NewBinding(key.WithKeys("shift+tab"))
+	addTaskKeyBinding      = key.NewBinding(key.WithKeys("ctrl+t"))
+	addEntryKeyBinding     = key.NewBinding(key.WithKeys("ctrl+l"))
+	quitKeyBinding         = key.NewBinding(key.WithKeys("ctrl+c"))
+)
+
+// quickAdd is a single line form for adding a task or a journal entry
+// without leaving the current pane
+type quickAdd struct {
+	input textinput.Model
+	save  func(string) error
+	title string
+}
+
+type Model struct {
+	active  pane
+	tasks   taskcmd.ListModel
+	journal journalcmd.EntryList
+	agenda  agenda
+	search  search
+	adding  *quickAdd
+	status  string
+	width   int
+	height  int
+}
+
+// New loads each pane with the tasks it shows when reloading, so the tasks
+// pane and the agenda leave out done tasks and search finds every task
+func New() (Model, error) {
+	tasks, err := task.ListTasks(false)
+	if err != nil {
+		return Model{}, err
+	}
+	all, err := task.ListTasks(true)
+	if err != nil {
+		return Model{}, err
+	}
+	entries, err := journal.GetEntries(true)
+	if err != nil {
+		return Model{}, err
+	}
+
+	return Model{
+		tasks:   taskcmd.NewListModel(tasks),
+		journal: journalcmd.NewEntryList(entries, true),
+		agenda:  newAgenda(tasks),
+		search:  newSearch(all, entries),
+	}, nil
+}
+
+// Run starts the dashboard, refreshing it whenever tasks or the journal change
+func Run() error {
+	model, err := New()
+	if err != nil {
+		return err
+	}
+	program := tea.NewProgram(model, tea.WithAltScreen())
+	for _, dir := range []string{task.Directory(), journal.Directory()} {
+		if stop, err := watch.Directory(program, dir); err == nil {
+			defer stop()
+		}
+	}
+	resume := logging.SuspendTerminal()
+	_, err = program.Run()
+	resume()
+	return err
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.tasks.Init()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m.broadcast(tea.WindowSizeMsg{Width: msg.Width, Height: msg.Height - tabBarHeight - 1})
+	case watch.ChangedMsg:
+		return m.broadcast(msg)
+	case tea.KeyMsg:
+		if key.Matches(msg, quitKeyBinding) {
+			return m, tea.Quit
+		}
+		if m.adding != nil {
+			return m.updateQuickAdd(msg)
+		}
+		if !m.capturingKeys() {
+			switch {
+			case key.Matches(msg, nextPaneKeyBinding):
+				return m.switchTo((m.active + 1) % pane(len(paneNames)))
+			case key.Matches(msg, previousPaneKeyBinding):
+				return m.switchTo((m.active + pane(len(paneNames)) - 1) % pane(len(paneNames)))
+			case key.Matches(msg, addTaskKeyBinding):
+				return m.openQuickAdd("New task", func(title string) error {
+					_, err := task.CreateTask(title, "", nil)
+					return err
+				})
+			case key.Matches(msg, addEntryKeyBinding):
+				return m.openQuickAdd("Journal", func(message string) error {
+					return journal.SaveJournalEntry(time.Now(), message)
+				})
+			}
+		}
+	}
+
+	return m.updateActive(msg)
+}
+
+// capturingKeys reports whether the active pane is taking text input, in
+// which case tab and the quick add keys belong to it
+func (m Model) capturingKeys() bool {
+	switch m.active {
+	case tasksPane:
+		return m.tasks.Filtering()
+	case journalPane:
+		return m.journal.Filtering()
+	}
+	return false
+}
+
+func (m Model) switchTo(active pane) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.active == searchPane {
+		m.search = m.search.blur()
+	}
+	m.active = active
+	if m.active == searchPane {
+		m.search, cmd = m.search.focus()
+	}
+	return m, cmd
+}
+
+// broadcast hands a message to every pane, not just the visible one
+func (m Model) broadcast(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var commands [4]tea.Cmd
+	var model tea.Model
+
+	model, commands[0] = m.tasks.Update(msg)
+	m.tasks = model.(taskcmd.ListModel)
+	model, commands[1] = m.journal.Update(msg)
+	m.journal = model.(journalcmd.EntryList)
+	m.agenda, commands[2] = m.agenda.Update(msg)
+	m.search, commands[3] = m.search.Update(msg)
+
+	return m, tea.Batch(commands[:]...)
+}
+
+func (m Model) updateActive(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var model tea.Model
+
+	switch m.active {
+	case tasksPane:
+		model, cmd = m.tasks.Update(msg)
+		m.tasks = model.(taskcmd.ListModel)
+	case journalPane:
+		model, cmd = m.journal.Update(msg)
+		m.journal = model.(journalcmd.EntryList)
+	case agendaPane:
+		m.agenda, cmd = m.agenda.Update(msg)
+	case searchPane:
+		m.search, cmd = m.search.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m Model) openQuickAdd(title string, save func(string) error) (tea.Model, tea.Cmd) {
+	input := textinput.New()
+	input.Placeholder = strings.ToLower(title)
+	input.Width = 50
+	m.adding = &quickAdd{input: input, save: save, title: title}
+	m.status = ""
+	return m, m.adding.input.Focus()
+}
+
+func (m Model) updateQuickAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.adding = nil
+		return m, nil
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.adding.input.Value())
+		if value != "" {
+			if err := m.adding.save(value); err != nil {
+				logging.Logger.Error("failed to save", zap.String("form", m.adding.title), zap.Error(err))
+				m.status = fmt.Sprintf("failed to save: %s", err)
+			} else {
+				m.status = fmt.Sprintf("saved: %s", value)
+			}
+		}
+		m.adding = nil
+		// the watchers pick up the new task or entry and refresh the panes
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.adding.input, cmd = m.adding.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	tabs := make([]string, len(paneNames))
+	for i, name := range paneNames {
+		style := tabStyle
+		if pane(i) == m.active {
+			style = activeTabStyle
+		}
+		tabs[i] = style.Render(name)
+	}
+	bar := lipgloss.JoinHorizontal(lipgloss.Top, tabs...) + statusStyle.Render("tab switch • ctrl+t task • ctrl+l journal • "+m.status)
+
+	var body string
+	switch m.active {
+	case tasksPane:
+		body = m.tasks.View()
+	case journalPane:
+		body = m.journal.View()
+	case agendaPane:
+		body = m.agenda.View()
+	case searchPane:
+		body = m.search.View()
+	}
+
+	if m.adding != nil {
+		form := overlayStyle.Render(fmt.Sprintf("%s\n\n%s\n\n%s", m.adding.title, m.adding.input.View(), statusStyle.Render("enter save • esc cancel")))
+		body = lipgloss.Place(m.width, m.height-tabBarHeight-1, lipgloss.Center, lipgloss.Center, form)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, "", bar, body)
+}
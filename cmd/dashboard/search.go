@@ -0,0 +1,107 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	config "noted/config"
+	"noted/journal"
+	"noted/task"
+	"noted/watch"
+	"strings"
+)
+
+// search finds tasks and journal entries containing every word typed
+type search struct {
+	input   textinput.Model
+	tasks   []task.Task
+	entries []journal.Entry
+	// err is why the last reload missed notes
+	err    error
+	height int
+}
+
+func newSearch(tasks []task.Task, entries []journal.Entry) search {
+	input := textinput.New()
+	input.Placeholder = "search tasks and journal"
+	input.Prompt = "/ "
+	return search{
+		input:   input,
+		tasks:   tasks,
+		entries: entries,
+	}
+}
+
+func (s search) focus() (search, tea.Cmd) {
+	return s, s.input.Focus()
+}
+
+func (s search) blur() search {
+	s.input.Blur()
+	return s
+}
+
+func (s search) Update(msg tea.Msg) (search, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		_, v := config.DocStyle.GetFrameSize()
+		s.height = msg.Height - v
+		return s, nil
+	case watch.ChangedMsg:
+		tasks, taskErr := task.ListTasks(true)
+		if tasks != nil {
+			s.tasks = tasks
+		}
+		entries, entryErr := journal.GetEntries(true)
+		if entries != nil {
+			s.entries = entries
+		}
+		s.err = errors.Join(taskErr, entryErr)
+		return s, nil
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return s, cmd
+}
+
+func (s search) View() string {
+	lines := []string{s.input.View(), ""}
+	if s.err != nil {
+		lines = append(lines, overdueStyle.Render(fmt.Sprintf("failed to reload: %s", s.err)), "")
+	}
+	terms := strings.Fields(strings.ToLower(s.input.Value()))
+
+	if len(terms) > 0 {
+		for _, t := range s.tasks {
+			if matches(terms, t.Task, t.Detail) {
+				lines = append(lines, fmt.Sprintf("%s %s", sectionStyle.Render(t.Status.AsString()), t.Title()))
+			}
+		}
+		for _, entry := range s.entries {
+			if matches(terms, entry.Message) {
+				lines = append(lines, fmt.Sprintf("%s %s", mutedStyle.Render(entry.Date().Format("2006-01-02")), strings.TrimSpace(entry.Message)))
+			}
+		}
+		if len(lines) == 2 && s.err == nil {
+			lines = append(lines, mutedStyle.Render("no matches"))
+		}
+	}
+
+	if s.height > 0 && len(lines) > s.height {
+		hidden := len(lines) - s.height + 1
+		lines = append(lines[:s.height-1], mutedStyle.Render(fmt.Sprintf("… %d more", hidden)))
+	}
+	return config.DocStyle.Render(strings.Join(lines, "\n"))
+}
+
+func matches(terms []string, fields ...string) bool {
+	text := strings.ToLower(strings.Join(fields, " "))
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/backup"
+	"noted/cmd/doctor"
+	"noted/journal"
+	"noted/storage"
+	"noted/task"
+)
+
+var doctorFix bool
+
+func init() {
+	DoctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "back up the store, then repair what can be repaired")
+	DoctorCmd.AddCommand(doctor.MergeConflictsCmd)
+}
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "check and repair the note store",
+	Long:  "Find and repair problems in the note store: unparsable task files, duplicate task ids, tasks in the file of another month, invalid statuses and malformed journal lines",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems, err := check(false)
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Println("no problems found")
+			return nil
+		}
+
+		fixable := 0
+		for _, problem := range problems {
+			if problem.Fixable {
+				fixable++
+			}
+		}
+
+		if !doctorFix || fixable == 0 {
+			for _, problem := range problems {
+				if problem.Fixable {
+					fmt.Printf("%s (fixable)\n", problem)
+				} else {
+					fmt.Println(problem)
+				}
+			}
+			if fixable > 0 {
+				fmt.Printf("%d problems found, %d can be fixed with --fix\n", len(problems), fixable)
+			}
+			return dataError{fmt.Errorf("%d problems found", len(problems))}
+		}
+
+		snapshot, err := backup.Create("doctor")
+		if err != nil {
+			return fmt.Errorf("failed to back up the store, nothing was repaired: %w", err)
+		}
+		fmt.Printf("backed up to %s\n", snapshot.Path())
+
+		if problems, err = check(true); err != nil {
+			return err
+		}
+		remaining := 0
+		for _, problem := range problems {
+			if problem.Fixable {
+				fmt.Printf("fixed %s\n", problem)
+			} else {
+				fmt.Println(problem)
+				remaining++
+			}
+		}
+		if remaining > 0 {
+			return dataError{fmt.Errorf("%d problems need fixing by hand", remaining)}
+		}
+		return nil
+	},
+}
+
+// check runs the checks of the task files and the journal
+func check(repair bool) ([]storage.Problem, error) {
+	problems, err := task.Check(repair)
+	if err != nil {
+		return problems, err
+	}
+	journalProblems, err := journal.Check(repair)
+	return append(problems, journalProblems...), err
+}
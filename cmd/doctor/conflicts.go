@@ -0,0 +1,56 @@
+package doctor
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/journal"
+	"noted/storage"
+	"noted/task"
+)
+
+var dryRun bool
+
+func init() {
+	MergeConflictsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the conflict copies")
+}
+
+var MergeConflictsCmd = &cobra.Command{
+	Use:   "merge-conflicts",
+	Short: "merge conflict copies left by file sync tools",
+	Long:  "find the conflict copies Syncthing or Dropbox made of task and journal files, merge them into the originals and remove them",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resolvers := []struct {
+			directory string
+			resolve   func(string) error
+		}{
+			{task.Directory(), task.ResolveConflict},
+			{journal.Directory(), journal.ResolveConflict},
+		}
+
+		found := 0
+		for _, resolver := range resolvers {
+			copies, err := storage.ConflictCopies(resolver.directory)
+			if err != nil {
+				return fmt.Errorf("failed to list conflict copies in %s: %w", resolver.directory, err)
+			}
+
+			for _, copyPath := range copies {
+				found++
+				if dryRun {
+					fmt.Printf("conflict copy: %s\n", copyPath)
+					continue
+				}
+				if err = resolver.resolve(copyPath); err != nil {
+					return fmt.Errorf("failed to merge conflict copy %s: %w", copyPath, err)
+				}
+				fmt.Printf("merged: %s\n", copyPath)
+			}
+		}
+
+		if found == 0 {
+			fmt.Println("no conflict copies found")
+		}
+		return nil
+	},
+}
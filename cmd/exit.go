@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/backup"
+	"noted/config"
+	"noted/journal"
+	"noted/storage"
+	"noted/task"
+	"noted/trash"
+	"noted/workspace"
+	"os"
+)
+
+// exit codes, so scripts can tell why noted failed
+const (
+	ExitError       = 1
+	ExitUsage       = 2
+	ExitNotFound    = 3
+	ExitInvalidData = 4
+	ExitConfig      = 5
+)
+
+// usageError is a command called with the wrong arguments or flags
+type usageError struct {
+	err error
+}
+
+func (u usageError) Error() string {
+	return u.err.Error()
+}
+
+func (u usageError) Unwrap() error {
+	return u.err
+}
+
+// configError is a configuration that cannot be used
+type configError struct {
+	err error
+}
+
+func (c configError) Error() string {
+	return c.err.Error()
+}
+
+func (c configError) Unwrap() error {
+	return c.err
+}
+
+// dataError is a store holding data noted cannot use
+type dataError struct {
+	err error
+}
+
+func (d dataError) Error() string {
+	return d.err.Error()
+}
+
+func (d dataError) Unwrap() error {
+	return d.err
+}
+
+// Execute runs the command line and returns the exit code for its outcome
+func Execute() int {
+	RootCmd.SilenceErrors = true
+	RootCmd.SilenceUsage = true
+	RootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	markUsageErrors(RootCmd)
+
+	cmd, err := RootCmd.ExecuteC()
+	if err == nil {
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	var usage usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return exitCode(err)
+}
+
+// markUsageErrors wraps the argument validation of every command, which
+// cobra reports as plain errors
+func markUsageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, child := range cmd.Commands() {
+		markUsageErrors(child)
+	}
+}
+
+func exitCode(err error) int {
+	var (
+		usage     usageError
+		config    configError
+		data      dataError
+		notFound  task.NotFoundError
+		ambiguous task.AmbiguousIdError
+		missing   task.MissingValueError
+		template  task.UnknownTemplateError
+		parse     storage.ParseError
+	)
+	switch {
+	case errors.As(err, &usage), errors.As(err, &ambiguous), errors.As(err, &missing),
+		errors.Is(err, noted.ErrUnknownSetting), errors.Is(err, noted.ErrFileExists), errors.Is(err, workspace.ErrInvalidName), errors.Is(err, workspace.ErrWorkspaceExists):
+		return ExitUsage
+	case errors.As(err, &config), errors.Is(err, noted.ErrInvalidValue), errors.Is(err, noted.ErrNotYAML):
+		return ExitConfig
+	case errors.As(err, &notFound), errors.As(err, &template), errors.Is(err, workspace.ErrUnknownWorkspace),
+		errors.Is(err, backup.ErrUnknownSnapshot), errors.Is(err, trash.ErrUnknownItem), errors.Is(err, journal.ErrNotFound):
+		return ExitNotFound
+	case errors.As(err, &parse), errors.As(err, &data):
+		return ExitInvalidData
+	default:
+		return ExitError
+	}
+}
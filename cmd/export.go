@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/interchange"
+)
+
+func init() {
+	ExportCmd.AddCommand(interchange.IcalExportCmd)
+	ExportCmd.AddCommand(interchange.TodoTxtExportCmd)
+	ExportCmd.AddCommand(interchange.OrgExportCmd)
+	ExportCmd.AddCommand(interchange.MarkdownExportCmd)
+	ExportCmd.AddCommand(interchange.HTMLExportCmd)
+}
+
+var ExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export notes to other formats",
+	Long:  "Export tasks and journal entries for use in other tools",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/interchange"
+)
+
+func init() {
+	ImportCmd.AddCommand(interchange.IcalImportCmd)
+	ImportCmd.AddCommand(interchange.TodoTxtImportCmd)
+	ImportCmd.AddCommand(interchange.OrgImportCmd)
+}
+
+var ImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import notes from other formats",
+	Long:  "Import tasks and journal entries from other tools",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
@@ -0,0 +1,72 @@
+package interchange
+
+import (
+	"fmt"
+	"github.com/emersion/go-ical"
+	"github.com/spf13/cobra"
+	"io"
+	"noted/calendar"
+	"noted/task"
+	"os"
+)
+
+var icalOutput string
+
+func init() {
+	IcalExportCmd.Flags().StringVarP(&icalOutput, "output", "o", "", "file to write to (default is stdout)")
+}
+
+var IcalExportCmd = &cobra.Command{
+	Use:   "ical",
+	Short: "export tasks as an iCalendar file",
+	Long:  "write every task as a VTODO, plus a VEVENT for each scheduled task, to an .ics file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := task.ListTasks(true)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if icalOutput != "" {
+			file, err := os.Create(icalOutput)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err = ical.NewEncoder(out).Encode(calendar.Export(tasks)); err != nil {
+			return fmt.Errorf("failed to export tasks: %w", err)
+		}
+		return nil
+	},
+}
+
+var IcalImportCmd = &cobra.Command{
+	Use:   "ical <file.ics>",
+	Short: "import tasks from an iCalendar file",
+	Long:  "create a task for every VTODO and VEVENT in an .ics file, updating tasks imported before instead of duplicating them",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open calendar: %w", err)
+		}
+		defer file.Close()
+
+		cal, err := ical.NewDecoder(file).Decode()
+		if err != nil {
+			return fmt.Errorf("failed to parse calendar %s: %w", args[0], err)
+		}
+
+		result, err := calendar.Import(cal)
+		if err != nil {
+			return fmt.Errorf("failed to import calendar %s: %w", args[0], err)
+		}
+
+		fmt.Printf("created %d, updated %d, unchanged %d, skipped %d\n", result.Created, result.Updated, result.Unchanged, result.Skipped)
+		return nil
+	},
+}
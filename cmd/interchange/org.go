@@ -0,0 +1,77 @@
+package interchange
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"noted/journal"
+	"noted/org"
+	"noted/task"
+	"os"
+)
+
+var orgOutput string
+
+func init() {
+	OrgExportCmd.Flags().StringVarP(&orgOutput, "output", "o", "", "file to write to (default is stdout)")
+}
+
+var OrgExportCmd = &cobra.Command{
+	Use:   "org",
+	Short: "export tasks and the journal as an Org file",
+	Long:  "write tasks as TODO headings, with subtasks nested below their parents, and the journal as a datetree to an .org file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := task.ListTasks(true)
+		if err != nil {
+			return err
+		}
+		entries, err := journal.GetEntries(false)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if orgOutput != "" {
+			file, err := os.Create(orgOutput)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err = org.Write(out, tasks, entries); err != nil {
+			return fmt.Errorf("failed to export notes: %w", err)
+		}
+		return nil
+	},
+}
+
+var OrgImportCmd = &cobra.Command{
+	Use:   "org <file.org>",
+	Short: "import tasks and journal entries from an Org file",
+	Long:  "create a task for every heading with a TODO keyword and a journal entry for every item in a datetree, updating tasks imported before instead of duplicating them",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open org file: %w", err)
+		}
+		defer file.Close()
+
+		doc, err := org.Parse(file)
+		if err != nil {
+			return fmt.Errorf("failed to parse org file %s: %w", args[0], err)
+		}
+
+		result, err := org.Import(doc)
+		if err != nil {
+			return fmt.Errorf("failed to import org file %s: %w", args[0], err)
+		}
+
+		fmt.Printf("tasks: created %d, updated %d, unchanged %d\n", result.Tasks.Created, result.Tasks.Updated, result.Tasks.Unchanged)
+		fmt.Printf("journal: added %d entries\n", result.Journal)
+		return nil
+	},
+}
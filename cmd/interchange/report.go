@@ -0,0 +1,66 @@
+package interchange
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"noted/journal"
+	"noted/report"
+	"noted/task"
+	"os"
+)
+
+var MarkdownExportCmd = newReportCmd("markdown", "Markdown", report.Markdown)
+
+var HTMLExportCmd = newReportCmd("html", "HTML", report.HTML)
+
+func newReportCmd(use string, name string, format report.Format) *cobra.Command {
+	var from, to, output string
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: "export a " + name + " report of the journal and tasks",
+		Long: "render journal entries grouped by day and tasks grouped by status into a " + name + " document, " +
+			"using report." + string(format) + ".tmpl from the storage directory's templates folder when it exists",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fromDate, err := task.ParseDate(from)
+			if err != nil {
+				return fmt.Errorf("invalid --from date: %w", err)
+			}
+			toDate, err := task.ParseDate(to)
+			if err != nil {
+				return fmt.Errorf("invalid --to date: %w", err)
+			}
+
+			entries, err := journal.GetEntries(false)
+			if err != nil {
+				return err
+			}
+			tasks, err := task.ListTasks(true)
+			if err != nil {
+				return err
+			}
+
+			var out io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %w", err)
+				}
+				defer file.Close()
+				out = file
+			}
+
+			if err = report.Render(out, format, report.New(fromDate, toDate, entries, tasks)); err != nil {
+				return fmt.Errorf("failed to render report: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "first day of the report (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "last day of the report (YYYY-MM-DD)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write to (default is stdout)")
+	return cmd
+}
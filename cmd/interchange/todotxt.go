@@ -0,0 +1,66 @@
+package interchange
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"noted/task"
+	"noted/todotxt"
+	"os"
+)
+
+var todoTxtOutput string
+
+func init() {
+	TodoTxtExportCmd.Flags().StringVarP(&todoTxtOutput, "output", "o", "", "file to write to (default is stdout)")
+}
+
+var TodoTxtExportCmd = &cobra.Command{
+	Use:   "todotxt",
+	Short: "export tasks as a todo.txt file",
+	Long:  "write every task as a todo.txt line, with priority, dates, +projects, @contexts and due: pairs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := task.ListTasks(true)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if todoTxtOutput != "" {
+			file, err := os.Create(todoTxtOutput)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err = todotxt.Write(out, tasks); err != nil {
+			return fmt.Errorf("failed to export tasks: %w", err)
+		}
+		return nil
+	},
+}
+
+var TodoTxtImportCmd = &cobra.Command{
+	Use:   "todotxt <todo.txt>",
+	Short: "import tasks from a todo.txt file",
+	Long:  "create a task for every line of a todo.txt file, updating tasks exported by noted before instead of duplicating them",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open todo.txt: %w", err)
+		}
+		defer file.Close()
+
+		result, err := todotxt.Import(file)
+		if err != nil {
+			return fmt.Errorf("failed to import todo.txt %s: %w", args[0], err)
+		}
+
+		fmt.Printf("created %d, updated %d, unchanged %d\n", result.Created, result.Updated, result.Unchanged)
+		return nil
+	},
+}
@@ -8,6 +8,7 @@ import (
 func init() {
 	JournalCmd.AddCommand(journal.AddToJournalCmd)
 	JournalCmd.AddCommand(journal.ListJournalCmd)
+	JournalCmd.AddCommand(journal.TodayJournalCmd)
 }
 
 var JournalCmd = &cobra.Command{
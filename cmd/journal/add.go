@@ -14,12 +14,13 @@ var AddToJournalCmd = &cobra.Command{
 	Use:   "add",
 	Short: "add a new journal command",
 	Long:  "Create a new journal entry",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		model := newEntry()
 		program := tea.NewProgram(model)
-		if _, err := program.Run(); err != nil {
-			logging.Logger.Fatal("program failure", zap.Error(err))
-		}
+		resume := logging.SuspendTerminal()
+		_, err := program.Run()
+		resume()
+		return err
 	},
 }
 
@@ -1,6 +1,8 @@
 package journal
 
 import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -8,62 +10,120 @@ import (
 	config "noted/config"
 	"noted/journal"
 	"noted/logging"
+	"noted/watch"
 )
 
-type entryList struct {
+type EntryList struct {
 	list list.Model
+	// embedded lists are part of a larger program, so selecting an entry
+	// does not quit
+	embedded bool
 }
 
 var ListJournalCmd = &cobra.Command{
 	Use:   "list",
 	Short: "list recent journal entries",
 	Long:  "list recent journal entries",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// first we need to read all entries
-		entries := journal.GetEntries(true)
-		program := tea.NewProgram(newEntryList(entries), tea.WithAltScreen())
-		if _, err := program.Run(); err != nil {
-			logging.Logger.Fatal("program failure", zap.Error(err))
+		entries, err := journal.GetEntries(true)
+		if err != nil {
+			return err
 		}
+		program := tea.NewProgram(NewEntryList(entries, false), tea.WithAltScreen())
+		if stop, err := watch.Directory(program, journal.Directory()); err == nil {
+			defer stop()
+		}
+		resume := logging.SuspendTerminal()
+		_, err = program.Run()
+		resume()
+		return err
 	},
 }
 
-func newEntryList(entries []journal.Entry) entryList {
+func NewEntryList(entries []journal.Entry, embedded bool) EntryList {
 	items := make([]list.Item, 0)
 	for _, journalEntry := range entries {
 		items = append(items, journalEntry)
 		//items = append(items, entry(fmt.Sprintf("%d/%s/%d: %s", journalEntry.Year, journalEntry.Month, journalEntry.Day, journalEntry.Message)))
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	l := list.New(items, newEntryItemDelegate(), 0, 0)
 	l.Title = config.TitleStyle.Render("Recent Journal Entries")
 	l.Styles.Title = config.TitleStyle
 	//l.Styles.PaginationStyle = paginationStyle
 	//l.Styles.HelpStyle = helpStyle
 
-	return entryList{
-		list: l,
+	return EntryList{
+		list:     l,
+		embedded: embedded,
 	}
 }
 
-func (e entryList) Init() tea.Cmd {
+func newEntryItemDelegate() list.DefaultDelegate {
+	deleteKeyBinding := key.NewBinding(
+		key.WithKeys("x", "delete"),
+		key.WithHelp("x", "move to trash"),
+	)
+	delegate := list.NewDefaultDelegate()
+
+	delegate.UpdateFunc = func(msg tea.Msg, model *list.Model) tea.Cmd {
+		entry, ok := model.SelectedItem().(journal.Entry)
+		if msg, isKey := msg.(tea.KeyMsg); ok && isKey && key.Matches(msg, deleteKeyBinding) {
+			return deleteEntry(model, entry)
+		}
+		return nil
+	}
+
+	help := []key.Binding{deleteKeyBinding}
+	delegate.ShortHelpFunc = func() []key.Binding {
+		return help
+	}
+	delegate.FullHelpFunc = func() [][]key.Binding {
+		return [][]key.Binding{help}
+	}
+
+	return delegate
+}
+
+func deleteEntry(model *list.Model, entry journal.Entry) tea.Cmd {
+	if err := journal.DeleteEntry(entry); err != nil {
+		logging.Logger.Error("failed to delete journal entry", zap.Error(err))
+		return model.NewStatusMessage(fmt.Sprintf("failed to delete entry: %s", err))
+	}
+	// Index is the position among the filtered items, RemoveItem takes one
+	// among all of them, so find the entry by content
+	for i, item := range model.Items() {
+		if item.(journal.Entry) == entry {
+			model.RemoveItem(i)
+			break
+		}
+	}
+	return model.NewStatusMessage("moved to the trash, `noted trash restore` brings it back")
+}
+
+func (e EntryList) Init() tea.Cmd {
 	return tea.EnterAltScreen
 }
 
-func (e entryList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (e EntryList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		h, v := config.DocStyle.GetFrameSize()
 		e.list.SetSize(msg.Width-h, msg.Height-v)
 
+	case watch.ChangedMsg:
+		return e, e.reload()
+
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			return e, tea.Quit
 
 		case tea.KeyEnter:
-			_ = e.list.SelectedItem().(journal.Entry)
-			return e, tea.Quit
+			if !e.embedded && e.list.FilterState() != list.Filtering {
+				return e, tea.Quit
+			}
 		}
 	}
 
@@ -72,6 +132,46 @@ func (e entryList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return e, cmd
 }
 
-func (e entryList) View() string {
+func (e *EntryList) reload() tea.Cmd {
+	selected, hasSelection := e.list.SelectedItem().(journal.Entry)
+	index := e.list.Index()
+
+	// a file broken while the list is open leaves out its entries until fixed
+	entries, err := journal.GetEntries(true)
+	if entries == nil {
+		return e.list.NewStatusMessage(fmt.Sprintf("failed to reload: %s", err))
+	}
+	items := make([]list.Item, 0)
+	for _, journalEntry := range entries {
+		items = append(items, journalEntry)
+	}
+	cmd := e.list.SetItems(items)
+	if err != nil {
+		cmd = tea.Batch(cmd, e.list.NewStatusMessage(fmt.Sprintf("failed to reload: %s", err)))
+	}
+
+	// entries have no identity of their own, so follow the selected one by content
+	if hasSelection && e.list.FilterState() == list.Unfiltered {
+		for i, item := range items {
+			if item.(journal.Entry) == selected {
+				index = i
+				break
+			}
+		}
+		if index >= len(items) {
+			index = len(items) - 1
+		}
+		e.list.Select(max(index, 0))
+	}
+
+	return cmd
+}
+
+func (e EntryList) View() string {
 	return config.DocStyle.Render(e.list.View())
 }
+
+// Filtering reports whether keys are going to the filter input
+func (e EntryList) Filtering() bool {
+	return e.list.FilterState() == list.Filtering
+}
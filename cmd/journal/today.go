@@ -0,0 +1,70 @@
+package journal
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/editor"
+	"noted/journal"
+	"noted/task"
+	"strings"
+	"time"
+)
+
+var todayTemplate string
+
+func init() {
+	TodayJournalCmd.Flags().StringVarP(&todayTemplate, "template", "t", journal.DefaultTemplate, "journal template to start the day from")
+}
+
+var TodayJournalCmd = &cobra.Command{
+	Use:   "today",
+	Short: "write today's journal",
+	Long:  "start today's journal from a template with prompts and today's open tasks in $EDITOR, or reopen it once today has entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		year, month, day := now.Date()
+
+		existing, err := journal.GetEntries(false)
+		if err != nil {
+			return err
+		}
+		written := make([]string, 0)
+		for _, entry := range existing {
+			if entry.Year == year && entry.Month == month.String() && entry.Day == day {
+				written = append(written, strings.TrimSpace(entry.Message))
+			}
+		}
+
+		var note string
+		if len(written) > 0 {
+			note = fmt.Sprintf("# %s\n# Add lines to add entries, removing a line does not delete its entry.\n%s\n",
+				now.Format("Monday, January 2 2006"), strings.Join(written, "\n"))
+		} else {
+			tasks, err := task.ListTasks(false)
+			if err != nil {
+				return err
+			}
+			if note, err = journal.RenderTemplate(todayTemplate, journal.NewTemplateData(now, tasks)); err != nil {
+				return fmt.Errorf("failed to render journal template %s: %w", todayTemplate, err)
+			}
+		}
+
+		edited, err := editor.Edit([]byte(note), "noted-journal-*.md")
+		if err != nil {
+			return fmt.Errorf("failed to edit journal: %w", err)
+		}
+
+		entries := make([]journal.Entry, 0)
+		for _, message := range journal.NoteEntries(note, string(edited)) {
+			entries = append(entries, journal.Entry{Year: year, Month: month.String(), Day: day, Message: message})
+		}
+
+		added, err := journal.AddEntries(entries)
+		if err != nil {
+			return fmt.Errorf("failed to save journal: %w", err)
+		}
+		fmt.Printf("added %d journal entries\n", added)
+		return nil
+	},
+}
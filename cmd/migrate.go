@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/backup"
+	"noted/storage"
+	"strings"
+)
+
+var migrateDryRun bool
+
+func init() {
+	MigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "only show the files that would be upgraded")
+}
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "upgrade the note store to the current file formats",
+	Long:  "Upgrade task and journal files written by older versions of noted to the current file formats, after backing up the store. Older files stay readable without it, but are only upgraded as they are rewritten.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		upgrades, err := storage.Migrate(true)
+		if len(upgrades) == 0 {
+			if err == nil {
+				fmt.Println("every file is up to date")
+			}
+			return err
+		}
+
+		if !migrateDryRun {
+			snapshot, backupErr := backup.Create("migrate")
+			if backupErr != nil {
+				return fmt.Errorf("failed to back up the store, nothing was upgraded: %w", backupErr)
+			}
+			fmt.Printf("backed up to %s\n", snapshot.Path())
+
+			if upgrades, err = storage.Migrate(false); len(upgrades) == 0 {
+				return err
+			}
+		}
+
+		for _, upgrade := range upgrades {
+			fmt.Printf("%s: version %d to %d, %s\n", upgrade.File, upgrade.From, upgrade.To, strings.Join(upgrade.Migrations, ", "))
+		}
+		if migrateDryRun {
+			fmt.Printf("%d files would be upgraded\n", len(upgrades))
+		} else {
+			fmt.Printf("%d files upgraded\n", len(upgrades))
+		}
+		return err
+	},
+}
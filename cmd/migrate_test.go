@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"github.com/spf13/viper"
+	"noted/backup"
+	"noted/config"
+	"noted/journal"
+	"noted/task"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+// files as noted wrote them before formats recorded their version
+const (
+	unversionedTasks = `entries:
+    - id: 0f8fad5b-d9cb-469f-a165-70867728950e
+      createdat: 2023-09-01T09:00:00Z
+      due_at: 2023-09-10T00:00:00Z
+      task: water plants
+      detail: the ones on the balcony
+      status: 2
+`
+	unversionedJournal = "- Friday 1: planted tulips\n- Saturday 2: raked leaves\n"
+)
+
+func migrate(t *testing.T, dryRun bool) {
+	t.Helper()
+	migrateDryRun = dryRun
+	defer func() { migrateDryRun = false }()
+	if err := MigrateCmd.RunE(MigrateCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func snapshots(t *testing.T) int {
+	t.Helper()
+	list, err := backup.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(list)
+}
+
+func TestMigrateUnversionedStore(t *testing.T) {
+	store := t.TempDir()
+	viper.Set(noted.ConfigStorageDir, store)
+	viper.Set(noted.ConfigTaskPrefix, "task")
+	viper.Set(noted.ConfigJournalPrefix, "journal")
+	viper.Set(noted.ConfigBackupKeep, 10)
+	taskFile := path.Join(store, "task", "2023-September.yaml")
+	journalFile := path.Join(store, "journal", "2023-September.md")
+	for file, contents := range map[string]string{taskFile: unversionedTasks, journalFile: unversionedJournal} {
+		if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrate(t, true)
+	if read(t, taskFile) != unversionedTasks || read(t, journalFile) != unversionedJournal || snapshots(t) != 0 {
+		t.Fatal("a dry run changed the store")
+	}
+
+	migrate(t, false)
+	if snapshots(t) != 1 {
+		t.Errorf("migrating took %d snapshots, want 1", snapshots(t))
+	}
+	migrated := read(t, taskFile)
+	if !strings.HasPrefix(migrated, "version: 1\n") {
+		t.Errorf("task file migrated to\n%s\nwant the version first", migrated)
+	}
+	if journalMigrated := read(t, journalFile); journalMigrated != "<!-- noted journal v1 -->\n"+unversionedJournal {
+		t.Errorf("journal migrated to\n%s\nwant the header in front of the entries", journalMigrated)
+	}
+
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Id != "0f8fad5b-d9cb-469f-a165-70867728950e" || tasks[0].Task != "water plants" ||
+		tasks[0].Detail != "the ones on the balcony" || tasks[0].Status != task.InProgress || tasks[0].DueAt == nil || tasks[0].DueAt.Day() != 10 {
+		t.Errorf("tasks after migrating are %+v, want the one task as it was", tasks)
+	}
+
+	// in the order of the file, messages keeping the space after the colon
+	entries, err := journal.GetEntries(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Day != 1 || strings.TrimSpace(entries[0].Message) != "planted tulips" ||
+		entries[1].Day != 2 || strings.TrimSpace(entries[1].Message) != "raked leaves" {
+		t.Errorf("journal after migrating is %+v, want both entries as they were", entries)
+	}
+
+	// a store already migrated is left alone
+	journalMigrated := read(t, journalFile)
+	migrate(t, false)
+	if read(t, taskFile) != migrated || read(t, journalFile) != journalMigrated {
+		t.Error("migrating again changed the files")
+	}
+	if snapshots(t) != 1 {
+		t.Errorf("migrating an up to date store took a snapshot")
+	}
+}
@@ -2,13 +2,21 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"log"
+	"noted/backup"
+	"noted/cmd/dashboard"
 	"noted/config"
+	"noted/gitsync"
 	"noted/logging"
+	"noted/storage"
+	"noted/trash"
+	"noted/workspace"
 	"os"
 	"path"
 )
@@ -16,20 +24,51 @@ import (
 func init() {
 	cobra.OnInitialize(initConfiguration)
 	RootCmd.PersistentFlags().StringVar(&configFile, "config", "", "configuration file (default is $HOME/.noted.yaml")
+	RootCmd.PersistentFlags().String("workspace", "", "workspace to use (default is the one chosen with `noted workspace use`)")
+	viper.BindPFlag(noted.ConfigWorkspace, RootCmd.PersistentFlags().Lookup("workspace"))
+	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log everything, including debug messages, to the terminal too")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only show errors on the terminal")
+	RootCmd.PersistentFlags().String("log-file", "", "file to log to (default is logs/noted.log in the storage directory)")
+	viper.BindPFlag(noted.ConfigLogFile, RootCmd.PersistentFlags().Lookup("log-file"))
+	RootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
 	RootCmd.AddCommand(JournalCmd)
 	RootCmd.AddCommand(TaskCmd)
+	RootCmd.AddCommand(SyncCmd)
+	RootCmd.AddCommand(DoctorCmd)
+	RootCmd.AddCommand(MigrateCmd)
+	RootCmd.AddCommand(BackupCmd)
+	RootCmd.AddCommand(TrashCmd)
+	RootCmd.AddCommand(CryptCmd)
+	RootCmd.AddCommand(ServeCmd)
+	RootCmd.AddCommand(ExportCmd)
+	RootCmd.AddCommand(ImportCmd)
+	RootCmd.AddCommand(StatsCmd)
+	RootCmd.AddCommand(WorkspaceCmd)
+	RootCmd.AddCommand(ConfigCmd)
+	// purging first lets the commit carry the purged trash
+	storage.OnChange(trash.AutoPurge)
+	storage.OnChange(gitsync.AutoCommit)
+	storage.OnChange(backup.AutoSnapshot)
+	storage.FindBasesWith(gitsync.ConflictBase)
 }
 
 var RootCmd = &cobra.Command{
-	Use:   "noted",
-	Short: "a note taking tool",
-	Long:  "Note.d is a note taking tool for kool kids 😎",
-	Run: func(cmd *cobra.Command, args []string) {
-
+	Use:               "noted",
+	Short:             "a note taking tool",
+	Long:              "Note.d is a note taking tool for kool kids 😎",
+	Args:              cobra.NoArgs,
+	PersistentPreRunE: checkConfiguration,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.Run()
 	},
 }
 
 var configFile string
+var verbose, quiet bool
+
+// configErr is kept rather than failing right away, so `noted config` can
+// still be used to repair the configuration
+var configErr error
 
 func initConfiguration() {
 	var home, homeErr = homedir.Dir()
@@ -40,32 +79,95 @@ func initConfiguration() {
 			log.Fatal(homeErr)
 		}
 		viper.AddConfigPath(home)
-		viper.SetConfigFile(".noted")
+		viper.SetConfigName(".noted")
 	}
 
-	// config defaults
-	viper.SetDefault(noted.ConfigStorageDir, path.Join(home, ".noted"))
-	viper.SetDefault(noted.ConfigJournalPrefix, "journal")
-	viper.SetDefault(noted.ConfigTaskPrefix, "task")
+	noted.SetDefaults(home)
 
 	if err := viper.ReadInConfig(); err != nil {
-		logging.Logger.Debug("cannot find config file")
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist) {
+			logging.Logger.Debug("cannot find config file")
+		} else {
+			configErr = fmt.Errorf("failed to read config file: %w", err)
+			return
+		}
 	}
 
-	// ensure filesystem is as we expect
-	storagePath := viper.GetString(noted.ConfigStorageDir)
-	journalPrefix := viper.GetString(noted.ConfigJournalPrefix)
-	taskPrefix := viper.GetString(noted.ConfigTaskPrefix)
-	journalPath := path.Join(storagePath, journalPrefix)
-	taskPath := path.Join(storagePath, taskPrefix)
+	storagePath, err := homedir.Expand(viper.GetString(noted.ConfigStorageDir))
+	if err != nil {
+		configErr = err
+		return
+	}
+	viper.Set(noted.ConfigStorageDir, storagePath)
 
-	directories := []string{storagePath, journalPath, taskPath}
+	if err = workspace.Activate(viper.GetString(noted.ConfigWorkspace)); err != nil {
+		configErr = err
+		return
+	}
+
+	config, err := noted.Load()
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		configErr = fmt.Errorf("invalid configuration in %s:\n%w", noted.File(), err)
+		return
+	}
+
+	// ensure filesystem is as we expect
+	directories := []string{
+		config.StorageDir,
+		path.Join(config.StorageDir, config.JournalPrefix),
+		path.Join(config.StorageDir, config.TaskPrefix),
+	}
 
 	for _, dir := range directories {
-		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
-			if err = os.MkdirAll(storagePath, 0755); err != nil {
-				logging.Logger.Fatal("failed to initialize directory", zap.String("directory", dir), zap.Error(err))
-			}
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			logging.Logger.Fatal("failed to initialize directory", zap.String("directory", dir), zap.Error(err))
+		}
+	}
+
+	configureLogging(config)
+}
+
+func configureLogging(config noted.Config) {
+	options := logging.Options{
+		TerminalLevel: zapcore.WarnLevel,
+		Format:        config.Log.Format,
+		File:          path.Join(config.StorageDir, "logs", "noted.log"),
+		MaxSize:       config.Log.MaxSize,
+		MaxBackups:    config.Log.MaxBackups,
+	}
+	// validated already
+	options.Level, _ = zapcore.ParseLevel(config.Log.Level)
+	if config.Log.File != "" {
+		options.File, _ = homedir.Expand(config.Log.File)
+	}
+
+	switch {
+	case verbose:
+		options.Level, options.TerminalLevel = zapcore.DebugLevel, zapcore.DebugLevel
+	case quiet:
+		options.TerminalLevel = zapcore.ErrorLevel
+	}
+
+	if err := logging.Configure(options); err != nil {
+		logging.Logger.Warn("logging to the terminal only", zap.Error(err))
+	}
+	logging.Logger.Debug("configuration loaded", zap.String("file", viper.ConfigFileUsed()), zap.String("workspace", workspace.Current()))
+}
+
+// checkConfiguration stops every command but `noted config` when the
+// configuration is broken
+func checkConfiguration(cmd *cobra.Command, args []string) error {
+	if configErr == nil {
+		return nil
+	}
+	for parent := cmd; parent != nil; parent = parent.Parent() {
+		if parent == ConfigCmd {
+			return nil
 		}
 	}
+	return configError{configErr}
 }
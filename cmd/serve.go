@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"net/http"
+	"noted/logging"
+	"noted/server"
+)
+
+var (
+	serveAddress string
+	serveCalDAV  bool
+)
+
+func init() {
+	ServeCmd.Flags().StringVar(&serveAddress, "addr", "127.0.0.1:8750", "address to listen on")
+	ServeCmd.Flags().BoolVar(&serveCalDAV, "caldav", false, "also publish tasks as a CalDAV calendar under "+server.CalDAVPrefix)
+}
+
+var ServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve notes over HTTP",
+	Long:  "Serve a local HTTP/JSON API for tasks and journal entries, and optionally a CalDAV calendar of tasks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logging.Logger.Info("listening", zap.String("address", serveAddress), zap.Bool("caldav", serveCalDAV))
+		fmt.Printf("listening on http://%s\n", serveAddress)
+		return http.ListenAndServe(serveAddress, server.NewHandler(serveCalDAV))
+	},
+}
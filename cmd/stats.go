@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/journal"
+	"noted/stats"
+	"noted/task"
+	"os"
+	"time"
+)
+
+var (
+	statsOutput string
+	statsWeeks  int
+	statsDays   int
+)
+
+func init() {
+	StatsCmd.Flags().StringVarP(&statsOutput, "output", "o", "text", "output format, text or json")
+	StatsCmd.Flags().IntVar(&statsWeeks, "weeks", 12, "number of weeks to chart tasks for")
+	StatsCmd.Flags().IntVar(&statsDays, "days", 14, "number of days to chart journal words for")
+}
+
+var StatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show statistics about tasks and the journal",
+	Long:  "Show tasks created and completed per week, time to completion, overdue tasks, journal streaks and words per day",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsWeeks < 1 || statsDays < 1 {
+			return usageError{fmt.Errorf("--weeks and --days must be positive")}
+		}
+		if statsOutput != "json" && statsOutput != "text" {
+			return usageError{fmt.Errorf("unknown output format %q, use text or json", statsOutput)}
+		}
+
+		tasks, err := task.ListTasks(true)
+		if err != nil {
+			return err
+		}
+		entries, err := journal.GetEntries(false)
+		if err != nil {
+			return err
+		}
+		summary := stats.Compute(tasks, entries, time.Now(), statsWeeks, statsDays)
+
+		if statsOutput == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summary)
+		}
+		fmt.Print(stats.Render(summary))
+		return nil
+	},
+}
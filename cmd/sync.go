@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/sync"
+)
+
+func init() {
+	SyncCmd.AddCommand(sync.InitSyncCmd)
+	SyncCmd.AddCommand(sync.PushSyncCmd)
+	SyncCmd.AddCommand(sync.PullSyncCmd)
+	SyncCmd.AddCommand(sync.StatusSyncCmd)
+}
+
+var SyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "synchronize notes with git",
+	Long:  "Version the note store with git and synchronize it with a remote",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
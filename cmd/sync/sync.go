@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/gitsync"
+)
+
+var InitSyncCmd = &cobra.Command{
+	Use:   "init [remote]",
+	Short: "version the storage directory with git",
+	Long:  "turn the storage directory into a git repository, optionally pushing to and pulling from the given remote",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote := ""
+		if len(args) == 1 {
+			remote = args[0]
+		}
+		if err := gitsync.Init(remote); err != nil {
+			return fmt.Errorf("failed to initialize git repository: %w", err)
+		}
+		fmt.Println("storage directory is now versioned with git")
+		return nil
+	},
+}
+
+var PushSyncCmd = &cobra.Command{
+	Use:   "push",
+	Short: "push local changes to the remote",
+	Long:  "push committed changes in the storage directory to the remote",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := gitsync.Push(); err != nil {
+			return fmt.Errorf("failed to push: %w", err)
+		}
+		return nil
+	},
+}
+
+var PullSyncCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "pull and merge changes from the remote",
+	Long:  "fetch the remote and merge it into the storage directory, merging tasks by id and journals by line",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := gitsync.Pull(); err != nil {
+			return fmt.Errorf("failed to pull: %w", err)
+		}
+		return nil
+	},
+}
+
+var StatusSyncCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the sync status",
+	Long:  "show uncommitted changes and how far the storage directory is ahead of or behind the remote",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		status, err := gitsync.Status()
+		if err != nil {
+			return fmt.Errorf("failed to read status: %w", err)
+		}
+		fmt.Print(status)
+		return nil
+	},
+}
@@ -8,6 +8,13 @@ import (
 func init() {
 	TaskCmd.AddCommand(task.AddTaskCmd)
 	TaskCmd.AddCommand(task.ListTasksCmd)
+	TaskCmd.AddCommand(task.DoneTaskCmd)
+	TaskCmd.AddCommand(task.StartTaskCmd)
+	TaskCmd.AddCommand(task.PauseTaskCmd)
+	TaskCmd.AddCommand(task.EditTaskCmd)
+	TaskCmd.AddCommand(task.NewTaskCmd)
+	TaskCmd.AddCommand(task.BoardTasksCmd)
+	TaskCmd.AddCommand(task.DeleteTaskCmd)
 }
 
 var TaskCmd = &cobra.Command{
@@ -7,8 +7,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
-	"go.uber.org/zap"
-	"log"
+	"noted/logging"
 	"noted/task"
 	"strings"
 )
@@ -20,6 +19,15 @@ type newTaskModel struct {
 	focusIndex int
 	cursorMode cursor.Mode
 	err        error
+	// editing holds the task being edited, creating a new task when nil
+	editing *task.Task
+	// embedded forms hand control back to their parent instead of quitting
+	embedded bool
+}
+
+type taskFormClosedMsg struct {
+	task  task.Task
+	saved bool
 }
 
 const (
@@ -35,6 +43,7 @@ var (
 	noStyle             = lipgloss.NewStyle()
 	helpStyle           = blurredStyle.Copy()
 	cursorModeHelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	errorStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 
 	focusedButton = focusedStyle.Copy().Render("[ Submit ]")
 	blurredButton = fmt.Sprintf("[ %s ]", blurredStyle.Render("Submit"))
@@ -44,12 +53,18 @@ var AddTaskCmd = &cobra.Command{
 	Use:   "add",
 	Short: "add task",
 	Long:  "create a new task",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		task := createNewTaskModel()
 		program := tea.NewProgram(task)
-		if _, err := program.Run(); err != nil {
-			log.Fatal("failed to run program", zap.Error(err))
+		resume := logging.SuspendTerminal()
+		result, err := program.Run()
+		resume()
+		if err != nil {
+			return err
+		} else if model, ok := result.(newTaskModel); ok && model.err != nil {
+			return fmt.Errorf("failed to save task: %w", model.err)
 		}
+		return nil
 	},
 }
 
@@ -77,7 +92,7 @@ func createNewTaskModel() newTaskModel {
 			t.TextStyle = noStyle
 			t.PromptStyle = noStyle
 		case dueDateInputId:
-			t.Placeholder = "due date"
+			t.Placeholder = "due date (YYYY-MM-DD)"
 			t.TextStyle = noStyle
 			t.PromptStyle = noStyle
 		}
@@ -88,6 +103,50 @@ func createNewTaskModel() newTaskModel {
 	return model
 }
 
+func createEditTaskModel(t task.Task) newTaskModel {
+	model := createNewTaskModel()
+	model.editing = &t
+	model.inputs[taskInputId].SetValue(t.Task)
+	model.inputs[detailInputId].SetValue(t.Detail)
+	if t.DueAt != nil {
+		model.inputs[dueDateInputId].SetValue(t.DueAt.Format("2006-01-02 15:04"))
+	}
+
+	return model
+}
+
+func (n newTaskModel) close(t task.Task, saved bool) tea.Cmd {
+	if !n.embedded {
+		return tea.Quit
+	}
+	return func() tea.Msg {
+		return taskFormClosedMsg{task: t, saved: saved}
+	}
+}
+
+func (n newTaskModel) submit() (newTaskModel, tea.Cmd) {
+	due, err := task.ParseDate(n.inputs[dueDateInputId].Value())
+	if err != nil {
+		n.err = err
+		return n, nil
+	}
+
+	if n.editing == nil {
+		created, err := task.CreateTask(n.inputs[taskInputId].Value(), n.inputs[detailInputId].Value(), due)
+		n.err = err
+		return n, n.close(created, n.err == nil)
+	}
+
+	edited := *n.editing
+	edited.Task = n.inputs[taskInputId].Value()
+	edited.Detail = n.inputs[detailInputId].Value()
+	edited.DueAt = due
+	if n.err = task.UpdateTask(edited); n.err != nil {
+		return n, nil
+	}
+	return n, n.close(edited, true)
+}
+
 func (n newTaskModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -96,8 +155,13 @@ func (n newTaskModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
+		case tea.KeyCtrlC:
 			return n, tea.Quit
+		case tea.KeyEsc:
+			if n.editing != nil {
+				return n, n.close(*n.editing, false)
+			}
+			return n, n.close(task.Task{}, false)
 		case tea.KeyCtrlR:
 			n.cursorMode++
 
@@ -114,10 +178,7 @@ func (n newTaskModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			command := msg.String()
 
 			if command == "enter" && n.focusIndex == len(n.inputs) {
-				// todo: handle time
-				n.err = task.CreateTask(n.inputs[taskInputId].Value(), n.inputs[detailInputId].Value(), nil)
-				// todo: process form data
-				return n, tea.Quit
+				return n.submit()
 			}
 
 			if command == "up" || command == "shift+tab" {
@@ -179,6 +240,10 @@ func (n newTaskModel) View() string {
 	}
 	fmt.Fprintf(&builder, "\n\n%s\n", button)
 
+	if n.err != nil {
+		fmt.Fprintf(&builder, "%s\n", errorStyle.Render(n.err.Error()))
+	}
+
 	builder.WriteString(helpStyle.Render("cursor mode is "))
 	builder.WriteString(cursorModeHelpStyle.Render(n.cursorMode.String()))
 	builder.WriteString(helpStyle.Render(" -- ctrl+r to change style"))
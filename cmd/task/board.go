@@ -0,0 +1,394 @@
+package task
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	config "noted/config"
+	"noted/logging"
+	"noted/task"
+	"noted/watch"
+	"strings"
+	"time"
+)
+
+// the columns of the board, in the order work moves through them
+var boardStatuses = []task.Status{task.ToDo, task.Scheduled, task.InProgress, task.Paused, task.Done, task.Cancelled}
+
+// a card is a bordered title and a line for its due date
+const (
+	cardHeight          = 4
+	collapsedColumnSize = 12
+)
+
+var (
+	columnTitleStyle  = lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	focusedTitleStyle = columnTitleStyle.Copy().Foreground(lipgloss.Color("205"))
+	cardStyle         = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+	selectedCardStyle = cardStyle.Copy().BorderForeground(lipgloss.Color("205"))
+	dueStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	overdueStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+type boardKeyMap struct {
+	Left      key.Binding
+	Right     key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	MoveLeft  key.Binding
+	MoveRight key.Binding
+	Edit      key.Binding
+	Collapse  key.Binding
+	Quit      key.Binding
+}
+
+func (k boardKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Left, k.Right, k.Up, k.Down, k.MoveLeft, k.MoveRight, k.Edit, k.Collapse, k.Quit}
+}
+
+func (k boardKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+var boardKeys = boardKeyMap{
+	Left:      key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "column")),
+	Right:     key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "column")),
+	Up:        key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:      key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	MoveLeft:  key.NewBinding(key.WithKeys("shift+left", "H"), key.WithHelp("H", "move card left")),
+	MoveRight: key.NewBinding(key.WithKeys("shift+right", "L"), key.WithHelp("L", "move card right")),
+	Edit:      key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+	Collapse:  key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "collapse done/cancelled")),
+	Quit:      key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+// boardColumn scrolls independently of the other columns
+type boardColumn struct {
+	status task.Status
+	tasks  []task.Task
+	cursor int
+	offset int
+}
+
+type BoardModel struct {
+	columns   []boardColumn
+	focus     int
+	collapsed bool
+	width     int
+	height    int
+	message   string
+	editor    *newTaskModel
+	help      help.Model
+}
+
+var BoardTasksCmd = &cobra.Command{
+	Use:   "board",
+	Short: "show tasks on a kanban board",
+	Long:  "show tasks in a column per status and move them between columns",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := task.ListTasks(true)
+		if err != nil {
+			return err
+		}
+		program := tea.NewProgram(newBoardModel(tasks), tea.WithAltScreen())
+		if stop, err := watch.Directory(program, task.Directory()); err == nil {
+			defer stop()
+		}
+		resume := logging.SuspendTerminal()
+		_, err = program.Run()
+		resume()
+		return err
+	},
+}
+
+func newBoardModel(tasks []task.Task) BoardModel {
+	board := BoardModel{help: help.New()}
+	for _, status := range boardStatuses {
+		board.columns = append(board.columns, boardColumn{status: status})
+	}
+	board.fill(tasks)
+	return board
+}
+
+// fill distributes tasks over the columns, keeping each column's cursor on
+// the task it was on
+func (b *BoardModel) fill(tasks []task.Task) {
+	for i := range b.columns {
+		column := &b.columns[i]
+		selected, hasSelection := column.selected()
+
+		column.tasks = column.tasks[:0]
+		for _, t := range tasks {
+			if t.Status == column.status {
+				column.tasks = append(column.tasks, t)
+			}
+		}
+
+		column.cursor = min(column.cursor, max(0, len(column.tasks)-1))
+		if hasSelection {
+			for j, t := range column.tasks {
+				if t.Id == selected.Id {
+					column.cursor = j
+				}
+			}
+		}
+	}
+}
+
+// reload refills the board from the store. A file broken while the board is
+// open leaves out its tasks until fixed.
+func (b *BoardModel) reload() {
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		b.message = fmt.Sprintf("failed to reload: %s", err)
+	}
+	if tasks != nil {
+		b.fill(tasks)
+	}
+}
+
+func (c boardColumn) selected() (task.Task, bool) {
+	if c.cursor < 0 || c.cursor >= len(c.tasks) {
+		return task.Task{}, false
+	}
+	return c.tasks[c.cursor], true
+}
+
+func (b BoardModel) isCollapsed(i int) bool {
+	status := b.columns[i].status
+	return b.collapsed && (status == task.Done || status == task.Cancelled)
+}
+
+func (b BoardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (b BoardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := config.DocStyle.GetFrameSize()
+		b.width, b.height = msg.Width-h, msg.Height-v
+		b.help.Width = b.width
+		b.scroll()
+		return b, nil
+	case watch.ChangedMsg:
+		b.reload()
+		b.scroll()
+		return b, nil
+	case openTaskEditorMsg:
+		editor := createEditTaskModel(msg.task)
+		editor.embedded = true
+		b.editor = &editor
+		return b, editor.Init()
+	case taskFormClosedMsg:
+		b.editor = nil
+		if msg.saved {
+			b.message = "task updated"
+			b.reload()
+		}
+		b.scroll()
+		return b, nil
+	}
+
+	if b.editor != nil {
+		editor, cmd := b.editor.Update(msg)
+		if form, ok := editor.(newTaskModel); ok {
+			b.editor = &form
+		}
+		return b, cmd
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return b, nil
+	}
+
+	column := &b.columns[b.focus]
+	switch {
+	case key.Matches(keyMsg, boardKeys.Quit):
+		return b, tea.Quit
+	case key.Matches(keyMsg, boardKeys.Left):
+		b.focus = b.nextColumn(-1)
+	case key.Matches(keyMsg, boardKeys.Right):
+		b.focus = b.nextColumn(1)
+	case key.Matches(keyMsg, boardKeys.Up):
+		column.cursor = max(0, column.cursor-1)
+	case key.Matches(keyMsg, boardKeys.Down):
+		column.cursor = max(0, min(len(column.tasks)-1, column.cursor+1))
+	case key.Matches(keyMsg, boardKeys.MoveLeft):
+		b.move(-1)
+	case key.Matches(keyMsg, boardKeys.MoveRight):
+		b.move(1)
+	case key.Matches(keyMsg, boardKeys.Edit):
+		if selected, ok := column.selected(); ok {
+			return b, func() tea.Msg {
+				return openTaskEditorMsg{task: selected}
+			}
+		}
+	case key.Matches(keyMsg, boardKeys.Collapse):
+		b.collapsed = !b.collapsed
+		if b.isCollapsed(b.focus) {
+			b.focus = b.nextColumn(-1)
+		}
+	}
+	b.scroll()
+	return b, nil
+}
+
+// scroll moves each column just enough to keep its cursor in view
+func (b *BoardModel) scroll() {
+	visibleCards := b.visibleCards()
+	for i := range b.columns {
+		column := &b.columns[i]
+		if column.cursor < column.offset {
+			column.offset = column.cursor
+		} else if column.cursor >= column.offset+visibleCards {
+			column.offset = column.cursor - visibleCards + 1
+		}
+		column.offset = max(0, min(column.offset, len(column.tasks)-visibleCards))
+	}
+}
+
+// footer is the last message above the key help
+func (b BoardModel) footer() string {
+	footer := b.help.View(boardKeys)
+	if b.message != "" {
+		footer = b.message + "\n" + footer
+	}
+	return footer
+}
+
+// visibleCards is how many cards fit in a column, below its title, which
+// takes two lines
+func (b BoardModel) visibleCards() int {
+	return max(1, (b.height-lipgloss.Height(b.footer())-2)/cardHeight)
+}
+
+// nextColumn finds the closest expanded column in direction, staying put at
+// the edges of the board
+func (b BoardModel) nextColumn(direction int) int {
+	for i := b.focus + direction; i >= 0 && i < len(b.columns); i += direction {
+		if !b.isCollapsed(i) {
+			return i
+		}
+	}
+	return b.focus
+}
+
+// move persists the selected card in the neighbouring column's status. The
+// focus follows the card unless it lands in a collapsed column.
+func (b *BoardModel) move(direction int) {
+	from := &b.columns[b.focus]
+	selected, ok := from.selected()
+	target := b.focus + direction
+	if !ok || target < 0 || target >= len(b.columns) {
+		return
+	}
+
+	selected.Status = b.columns[target].status
+	if err := task.UpdateTask(selected); err != nil {
+		logging.Logger.Error("failed to update task item", zap.Error(err))
+		b.message = fmt.Sprintf("failed to update task: %s", err)
+		return
+	}
+
+	from.tasks = append(from.tasks[:from.cursor], from.tasks[from.cursor+1:]...)
+	from.cursor = max(0, min(from.cursor, len(from.tasks)-1))
+	to := &b.columns[target]
+	to.tasks = append(to.tasks, selected)
+	if !b.isCollapsed(target) {
+		to.cursor = len(to.tasks) - 1
+		b.focus = target
+	}
+	b.message = fmt.Sprintf("task moved to %s", selected.Status.AsString())
+}
+
+func (b BoardModel) View() string {
+	if b.editor != nil {
+		return config.DocStyle.Render(b.editor.View())
+	}
+
+	expanded := 0
+	for i := range b.columns {
+		if !b.isCollapsed(i) {
+			expanded++
+		}
+	}
+	width := b.width - (len(b.columns)-expanded)*collapsedColumnSize
+	columnWidth := max(collapsedColumnSize, width/max(1, expanded))
+
+	footer := b.footer()
+	visibleCards := b.visibleCards()
+
+	columns := make([]string, len(b.columns))
+	for i := range b.columns {
+		if b.isCollapsed(i) {
+			columns[i] = b.columns[i].collapsedView()
+		} else {
+			columns[i] = b.columns[i].view(columnWidth, visibleCards, i == b.focus)
+		}
+	}
+
+	board := lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+	return config.DocStyle.Render(lipgloss.JoinVertical(lipgloss.Left, board, footer))
+}
+
+func (c boardColumn) view(width int, visibleCards int, focused bool) string {
+	titleStyle := columnTitleStyle
+	if focused {
+		titleStyle = focusedTitleStyle
+	}
+	lines := []string{titleStyle.Render(fmt.Sprintf("%s (%d)", c.status.AsString(), len(c.tasks)))}
+	if c.offset > 0 {
+		lines = append(lines, dueStyle.Render(fmt.Sprintf(" ↑ %d more", c.offset)))
+	} else {
+		lines = append(lines, "")
+	}
+
+	end := min(len(c.tasks), c.offset+visibleCards)
+	for i := c.offset; i < end; i++ {
+		style := cardStyle
+		if focused && i == c.cursor {
+			style = selectedCardStyle
+		}
+		lines = append(lines, style.Width(width-2).Render(card(c.tasks[i], width-6)))
+	}
+	if end < len(c.tasks) {
+		lines = append(lines, dueStyle.Render(fmt.Sprintf(" ↓ %d more", len(c.tasks)-end)))
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (c boardColumn) collapsedView() string {
+	return lipgloss.NewStyle().Width(collapsedColumnSize).Render(
+		columnTitleStyle.Render(c.status.AsString()) + "\n" + dueStyle.Render(fmt.Sprintf(" %d tasks", len(c.tasks))))
+}
+
+func card(t task.Task, width int) string {
+	title := t.Title()
+	if width > 1 {
+		// by cells, as wide characters take two
+		title = runewidth.Truncate(title, width, "…")
+	}
+
+	due := ""
+	if t.DueAt != nil {
+		style := dueStyle
+		if t.Overdue(time.Now()) {
+			style = overdueStyle
+		}
+		layout := "Jan 2 15:04"
+		if t.DueAt.Hour() == 0 && t.DueAt.Minute() == 0 {
+			layout = "Jan 2"
+		}
+		due = style.Render("due " + t.DueAt.Format(layout))
+	}
+	return title + "\n" + due
+}
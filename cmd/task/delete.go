@@ -0,0 +1,25 @@
+package task
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/task"
+)
+
+var DeleteTaskCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "delete a task",
+	Long:  "move the task with the given id (or unique id prefix) to the trash, from where `noted trash restore` brings it back",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskItem, err := task.FindTask(args[0])
+		if err != nil {
+			return err
+		}
+		if err = task.DeleteTask(taskItem); err != nil {
+			return fmt.Errorf("failed to delete task %s: %w", taskItem.Id, err)
+		}
+		fmt.Printf("moved to the trash: %s\n", taskItem.Title())
+		return nil
+	},
+}
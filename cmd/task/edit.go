@@ -0,0 +1,80 @@
+package task
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"noted/editor"
+	"noted/task"
+)
+
+var (
+	editTitle  string
+	editDetail string
+	editDue    string
+)
+
+func init() {
+	EditTaskCmd.Flags().StringVar(&editTitle, "title", "", "new title for the task")
+	EditTaskCmd.Flags().StringVar(&editDetail, "detail", "", "new detail for the task")
+	EditTaskCmd.Flags().StringVar(&editDue, "due", "", "new due date (YYYY-MM-DD or YYYY-MM-DD HH:MM), \"none\" clears it")
+}
+
+var EditTaskCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "edit a task",
+	Long:  "edit a task's title, detail and due date with flags, or in $EDITOR when no flags are given",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskItem, err := task.FindTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		if flags.Changed("title") || flags.Changed("detail") || flags.Changed("due") {
+			if flags.Changed("title") {
+				taskItem.Task = editTitle
+			}
+			if flags.Changed("detail") {
+				taskItem.Detail = editDetail
+			}
+			if flags.Changed("due") {
+				if editDue == "none" {
+					taskItem.DueAt = nil
+				} else if taskItem.DueAt, err = task.ParseDate(editDue); err != nil {
+					return fmt.Errorf("invalid due date %q: %w", editDue, err)
+				}
+			}
+		} else if taskItem, err = editInEditor(taskItem); err != nil {
+			return fmt.Errorf("failed to edit task %s: %w", taskItem.Id, err)
+		}
+
+		if err = task.UpdateTask(taskItem); err != nil {
+			return fmt.Errorf("failed to update task %s: %w", taskItem.Id, err)
+		}
+
+		fmt.Printf("updated: %s\n", taskItem.Title())
+		return nil
+	},
+}
+
+func editInEditor(taskItem task.Task) (task.Task, error) {
+	data, err := yaml.Marshal(taskItem.ToEntry())
+	if err != nil {
+		return taskItem, err
+	}
+
+	if data, err = editor.Edit(data, "noted-task-*.yaml"); err != nil {
+		return taskItem, err
+	}
+
+	var entry task.Entry
+	if err = yaml.Unmarshal(data, &entry); err != nil {
+		return taskItem, err
+	}
+
+	// the id and file locate the task on disk, so they cannot be edited
+	entry.Id = taskItem.Id
+	return entry.ToTask(taskItem.File), nil
+}
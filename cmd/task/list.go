@@ -10,13 +10,20 @@ import (
 	config "noted/config"
 	"noted/logging"
 	"noted/task"
+	"noted/watch"
 )
 
 type ListModel struct {
-	list list.Model
+	list   list.Model
+	picker *statusPicker
+	editor *newTaskModel
 }
 
-func newListModel(tasks []task.Task) ListModel {
+type openTaskEditorMsg struct {
+	task task.Task
+}
+
+func NewListModel(tasks []task.Task) ListModel {
 	items := make([]list.Item, 0)
 
 	for _, t := range tasks {
@@ -26,6 +33,8 @@ func newListModel(tasks []task.Task) ListModel {
 	taskList := list.New(items, newTaskItemDelegate(), 0, 0)
 	taskList.Title = config.TitleStyle.Render("Tasks")
 	taskList.Styles.Title = config.TitleStyle
+	// "d" marks a task as done, so it can no longer page forward
+	taskList.KeyMap.NextPage.SetKeys("right", "l", "pgdown", "f")
 
 	return ListModel{
 		list: taskList,
@@ -43,41 +52,48 @@ func newTaskItemDelegate() list.DefaultDelegate {
 		key.WithHelp("s", "iterate status"),
 	)
 
-	cancelKeyBinding := key.NewBinding(
-		key.WithKeys("x", "backspace"),
-		key.WithHelp("x", "cancel"),
+	doneKeyBinding := key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "done"),
+	)
+
+	startKeyBinding := key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "in progress"),
+	)
+
+	pauseKeyBinding := key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pause"),
+	)
+
+	editKeyBinding := key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit"),
 	)
 	delegate := list.NewDefaultDelegate()
 
 	delegate.UpdateFunc = func(msg tea.Msg, model *list.Model) tea.Cmd {
 		if taskItem, ok := model.SelectedItem().(task.Task); ok {
-			title := taskItem.Title()
 			switch msg := msg.(type) {
 			case tea.KeyMsg:
 				switch {
 				case key.Matches(msg, selectionKeyBinding):
-					return model.NewStatusMessage(title)
+					return openStatusPicker(taskItem)
 				case key.Matches(msg, rotateStatusKeyBinding):
 					if taskItem.Status == task.Done {
-						taskItem.Status = task.ToDo
-					} else {
-						taskItem.Status++
-					}
-					if err := task.UpdateTask(taskItem); err != nil {
-						logging.Logger.Error("failed to update task item", zap.Error(err))
-						return nil
-					} else {
-						model.Items()[model.Index()] = taskItem
-						return model.NewStatusMessage(fmt.Sprintf("task updated to %s", taskItem.Status.AsString()))
+						return updateTaskStatus(model, taskItem, task.ToDo)
 					}
-				case key.Matches(msg, cancelKeyBinding):
-					taskItem.Status = task.Cancelled
-					if err := task.UpdateTask(taskItem); err != nil {
-						logging.Logger.Error("failed to update task item", zap.Error(err))
-						tea.Quit()
-					} else {
-						model.Items()[model.Index()] = taskItem
-						return model.NewStatusMessage("task cancelled")
+					return updateTaskStatus(model, taskItem, taskItem.Status+1)
+				case key.Matches(msg, doneKeyBinding):
+					return updateTaskStatus(model, taskItem, task.Done)
+				case key.Matches(msg, startKeyBinding):
+					return updateTaskStatus(model, taskItem, task.InProgress)
+				case key.Matches(msg, pauseKeyBinding):
+					return updateTaskStatus(model, taskItem, task.Paused)
+				case key.Matches(msg, editKeyBinding):
+					return func() tea.Msg {
+						return openTaskEditorMsg{task: taskItem}
 					}
 				}
 			}
@@ -87,7 +103,7 @@ func newTaskItemDelegate() list.DefaultDelegate {
 		}
 	}
 
-	help := []key.Binding{selectionKeyBinding, rotateStatusKeyBinding, cancelKeyBinding}
+	help := []key.Binding{selectionKeyBinding, rotateStatusKeyBinding, doneKeyBinding, startKeyBinding, pauseKeyBinding, editKeyBinding}
 	delegate.ShortHelpFunc = func() []key.Binding {
 		return help
 	}
@@ -98,16 +114,42 @@ func newTaskItemDelegate() list.DefaultDelegate {
 	return delegate
 }
 
+func updateTaskStatus(model *list.Model, taskItem task.Task, status task.Status) tea.Cmd {
+	taskItem.Status = status
+	if err := task.UpdateTask(taskItem); err != nil {
+		logging.Logger.Error("failed to update task item", zap.Error(err))
+		return model.NewStatusMessage(fmt.Sprintf("failed to update task: %s", err))
+	}
+
+	return tea.Batch(replaceTaskItem(model, taskItem), model.NewStatusMessage(fmt.Sprintf("task updated to %s", status.AsString())))
+}
+
+func replaceTaskItem(model *list.Model, taskItem task.Task) tea.Cmd {
+	for i, item := range model.Items() {
+		if t, ok := item.(task.Task); ok && t.Id == taskItem.Id {
+			return model.SetItem(i, taskItem)
+		}
+	}
+	return nil
+}
+
 var ListTasksCmd = &cobra.Command{
 	Use:   "list",
 	Short: "list and alter todo list items",
 	Long:  "manage your task list",
-	Run: func(cmd *cobra.Command, args []string) {
-		tasks := task.ListTasks(false)
-		program := tea.NewProgram(newListModel(tasks))
-		if _, err := program.Run(); err != nil {
-			logging.Logger.Fatal("failed to execute program", zap.Error(err))
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := task.ListTasks(false)
+		if err != nil {
+			return err
+		}
+		program := tea.NewProgram(NewListModel(tasks))
+		if stop, err := watch.Directory(program, task.Directory()); err == nil {
+			defer stop()
 		}
+		resume := logging.SuspendTerminal()
+		_, err = program.Run()
+		resume()
+		return err
 	},
 }
 
@@ -122,6 +164,48 @@ func (l ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		h, v := config.DocStyle.GetFrameSize()
 		l.list.SetSize(msg.Width-h, msg.Height-v)
+	case openStatusPickerMsg:
+		l.picker = newStatusPicker(msg.task)
+		return l, nil
+	case openTaskEditorMsg:
+		editor := createEditTaskModel(msg.task)
+		editor.embedded = true
+		l.editor = &editor
+		return l, editor.Init()
+	case watch.ChangedMsg:
+		return l, l.reload()
+	case taskFormClosedMsg:
+		l.editor = nil
+		if msg.saved {
+			return l, tea.Batch(replaceTaskItem(&l.list, msg.task), l.list.NewStatusMessage("task updated"))
+		}
+		return l, nil
+	}
+
+	if l.editor != nil {
+		editor, cmd := l.editor.Update(msg)
+		if form, ok := editor.(newTaskModel); ok {
+			l.editor = &form
+		}
+		return l, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if l.picker != nil {
+			if msg.Type == tea.KeyCtrlC {
+				return l, tea.Quit
+			}
+			status, selected, done := l.picker.update(msg)
+			taskItem := l.picker.task
+			if done {
+				l.picker = nil
+			}
+			if selected {
+				return l, updateTaskStatus(&l.list, taskItem, status)
+			}
+			return l, nil
+		}
 	}
 
 	newModel, cmd := l.list.Update(msg)
@@ -131,6 +215,47 @@ func (l ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return l, tea.Batch(commands...)
 }
 
+func (l *ListModel) reload() tea.Cmd {
+	selected, hasSelection := l.list.SelectedItem().(task.Task)
+
+	// a file broken while the list is open leaves out its tasks until fixed
+	tasks, err := task.ListTasks(false)
+	if tasks == nil {
+		return l.list.NewStatusMessage(fmt.Sprintf("failed to reload: %s", err))
+	}
+	items := make([]list.Item, 0)
+	for _, t := range tasks {
+		items = append(items, t)
+	}
+	cmd := l.list.SetItems(items)
+	if err != nil {
+		cmd = tea.Batch(cmd, l.list.NewStatusMessage(fmt.Sprintf("failed to reload: %s", err)))
+	}
+
+	// keep the cursor on the same task even if others were added or removed around it
+	if hasSelection && l.list.FilterState() == list.Unfiltered {
+		for i, item := range items {
+			if item.(task.Task).Id == selected.Id {
+				l.list.Select(i)
+				break
+			}
+		}
+	}
+
+	return cmd
+}
+
 func (l ListModel) View() string {
+	if l.editor != nil {
+		return config.DocStyle.Render(l.editor.View())
+	}
+	if l.picker != nil {
+		return config.DocStyle.Render(l.picker.view())
+	}
 	return config.DocStyle.Render(l.list.View())
 }
+
+// Filtering reports whether keys are going to the filter input or a form
+func (l ListModel) Filtering() bool {
+	return l.list.FilterState() == list.Filtering || l.editor != nil || l.picker != nil
+}
@@ -0,0 +1,65 @@
+package task
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/task"
+	"strings"
+	"time"
+)
+
+var (
+	templateName string
+	templateVars map[string]string
+)
+
+func init() {
+	NewTaskCmd.Flags().StringVarP(&templateName, "template", "t", "", "name of the template in the storage directory's templates/tasks folder")
+	NewTaskCmd.Flags().StringToStringVar(&templateVars, "var", map[string]string{}, "value for a template placeholder, as name=value")
+}
+
+var NewTaskCmd = &cobra.Command{
+	Use:   "new",
+	Short: "create tasks from a template",
+	Long:  "create a task and its subtasks from a YAML task template, filling in {{placeholders}} from --var; lists the templates when none is given",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if templateName == "" {
+			names, err := task.Templates()
+			if err != nil {
+				return fmt.Errorf("failed to list task templates in %s: %w", task.TemplateDirectory(), err)
+			}
+			if len(names) == 0 {
+				fmt.Printf("no task templates in %s\n", task.TemplateDirectory())
+				return nil
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+			return nil
+		}
+
+		template, err := task.LoadTemplate(templateName)
+		if err != nil {
+			return err
+		}
+
+		created, err := template.Instantiate(templateVars, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to create tasks from template %s: %w", templateName, err)
+		}
+
+		depth := map[string]int{}
+		for _, t := range created {
+			if t.Parent != "" {
+				depth[t.Id] = depth[t.Parent] + 1
+			}
+			due := ""
+			if t.DueAt != nil {
+				due = fmt.Sprintf(" (due %s)", t.DueAt.Format("2006-01-02 15:04"))
+			}
+			fmt.Printf("%screated: %s%s\n", strings.Repeat("  ", depth[t.Id]), t.Title(), due)
+		}
+		return nil
+	},
+}
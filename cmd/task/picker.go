@@ -0,0 +1,89 @@
+package task
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"noted/task"
+	"strings"
+)
+
+type openStatusPickerMsg struct {
+	task task.Task
+}
+
+type statusPicker struct {
+	task   task.Task
+	cursor int
+}
+
+var (
+	pickerUpKeyBinding = key.NewBinding(
+		key.WithKeys("up", "k"),
+	)
+	pickerDownKeyBinding = key.NewBinding(
+		key.WithKeys("down", "j"),
+	)
+	pickerSelectKeyBinding = key.NewBinding(
+		key.WithKeys("enter"),
+	)
+	pickerCancelKeyBinding = key.NewBinding(
+		key.WithKeys("esc", "q"),
+	)
+)
+
+func newStatusPicker(t task.Task) *statusPicker {
+	return &statusPicker{
+		task:   t,
+		cursor: int(t.Status),
+	}
+}
+
+func openStatusPicker(t task.Task) tea.Cmd {
+	return func() tea.Msg {
+		return openStatusPickerMsg{task: t}
+	}
+}
+
+// update returns the chosen status once the user selects one, and done when
+// the picker should be closed.
+func (p *statusPicker) update(msg tea.KeyMsg) (status task.Status, selected bool, done bool) {
+	switch {
+	case key.Matches(msg, pickerUpKeyBinding):
+		if p.cursor > task.ToDo {
+			p.cursor--
+		}
+	case key.Matches(msg, pickerDownKeyBinding):
+		if p.cursor < task.Done {
+			p.cursor++
+		}
+	case key.Matches(msg, pickerSelectKeyBinding):
+		return task.Status(p.cursor), true, true
+	case key.Matches(msg, pickerCancelKeyBinding):
+		return p.task.Status, false, true
+	default:
+		if len(msg.Runes) == 1 && msg.Runes[0] >= '1' && msg.Runes[0] <= '1'+task.Done {
+			return task.Status(msg.Runes[0] - '1'), true, true
+		}
+	}
+	return p.task.Status, false, false
+}
+
+func (p *statusPicker) view() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("change status of %q\n\n", p.task.Title()))
+	for s := task.ToDo; s <= task.Done; s++ {
+		line := fmt.Sprintf("%d. %s", s+1, task.Status(s).AsString())
+		if s == p.cursor {
+			builder.WriteString(focusedStyle.Render("> " + line))
+		} else {
+			builder.WriteString("  " + line)
+		}
+		builder.WriteRune('\n')
+	}
+	builder.WriteRune('\n')
+	builder.WriteString(helpStyle.Render("enter: select • 1-6: choose • esc: cancel"))
+
+	return builder.String()
+}
@@ -0,0 +1,36 @@
+package task
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/task"
+)
+
+var DoneTaskCmd = newStatusCmd("done", "mark a task as done", task.Done)
+
+var StartTaskCmd = newStatusCmd("start", "mark a task as in progress", task.InProgress)
+
+var PauseTaskCmd = newStatusCmd("pause", "pause a task", task.Paused)
+
+func newStatusCmd(use string, short string, status task.Status) *cobra.Command {
+	return &cobra.Command{
+		Use:   fmt.Sprintf("%s <id>", use),
+		Short: short,
+		Long:  fmt.Sprintf("set the status of the task with the given id (or unique id prefix) to %s", status.AsString()),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			taskItem, err := task.FindTask(args[0])
+			if err != nil {
+				return err
+			}
+
+			taskItem.Status = status
+			if err = task.UpdateTask(taskItem); err != nil {
+				return fmt.Errorf("failed to update task %s: %w", taskItem.Id, err)
+			}
+
+			fmt.Printf("%s: %s\n", status.AsString(), taskItem.Title())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/trash"
+)
+
+func init() {
+	TrashCmd.AddCommand(trash.ListTrashCmd)
+	TrashCmd.AddCommand(trash.RestoreTrashCmd)
+	TrashCmd.AddCommand(trash.EmptyTrashCmd)
+}
+
+var TrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "manage deleted tasks and journal entries",
+	Long:  "Deleted tasks and journal entries go to the trash, where they can be restored until they are purged",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
@@ -0,0 +1,69 @@
+package trash
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/trash"
+)
+
+var ListTrashCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the trash",
+	Long:  "list deleted tasks and journal entries, most recently deleted first, after purging those past trash.retention",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := trash.Purge(); err != nil {
+			return err
+		}
+		items, err := trash.List()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Println("the trash is empty")
+		}
+		for _, item := range items {
+			fmt.Printf("%-8s  %s  %-7s %s (%s)\n", shortId(item.Id), item.DeletedAt.Format("2006-01-02 15:04"), item.Kind, item.Title, item.File)
+		}
+		return nil
+	},
+}
+
+var RestoreTrashCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "restore an item from the trash",
+	Long:  "put the deleted task or journal entry with the given id (or unique id prefix) back where it was deleted from",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		item, err := trash.Restore(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored %s %q to %s\n", item.Kind, item.Title, item.File)
+		return nil
+	},
+}
+
+var EmptyTrashCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "empty the trash",
+	Long:  "permanently remove every deleted task and journal entry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		emptied, err := trash.Empty()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("removed %d items\n", emptied)
+		return nil
+	},
+}
+
+// shortId is enough of an id to restore an item by, ids written by hand can
+// be shorter
+func shortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"noted/cmd/workspace"
+)
+
+func init() {
+	WorkspaceCmd.AddCommand(workspace.ListWorkspaceCmd)
+	WorkspaceCmd.AddCommand(workspace.UseWorkspaceCmd)
+	WorkspaceCmd.AddCommand(workspace.CreateWorkspaceCmd)
+	WorkspaceCmd.AddCommand(workspace.TasksWorkspaceCmd)
+	WorkspaceCmd.AddCommand(workspace.SearchWorkspaceCmd)
+}
+
+var WorkspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "manage workspaces",
+	Long:  "Keep separate notes, such as for work and home, in workspaces",
+	Run: func(cmd *cobra.Command, args []string) {
+
+	},
+}
@@ -0,0 +1,115 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/journal"
+	"noted/task"
+	"noted/workspace"
+	"os"
+	"strings"
+)
+
+var includeCompleted bool
+
+func init() {
+	TasksWorkspaceCmd.Flags().BoolVarP(&includeCompleted, "all", "a", false, "include done and cancelled tasks")
+}
+
+var TasksWorkspaceCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "list tasks of every workspace",
+	Long:  "list the open tasks of every workspace, prefixed with the workspace they belong to",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return workspace.Each(func(w workspace.Workspace) error {
+			found, err := tasks()
+			for _, t := range found {
+				if includeCompleted || t.Open() {
+					printTask(w, t)
+				}
+			}
+			return err
+		})
+	},
+}
+
+var SearchWorkspaceCmd = &cobra.Command{
+	Use:   "search <terms>...",
+	Short: "search tasks and journals of every workspace",
+	Long:  "find the tasks and journal entries of every workspace containing all the given terms, ignoring case",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		terms := make([]string, len(args))
+		for i, arg := range args {
+			terms[i] = strings.ToLower(arg)
+		}
+
+		found := 0
+		err := workspace.Each(func(w workspace.Workspace) error {
+			tasks, err := tasks()
+			if err != nil {
+				return err
+			}
+			entries, err := entries()
+			if err != nil {
+				return err
+			}
+
+			for _, t := range tasks {
+				if matches(terms, t.Task, t.Detail) {
+					printTask(w, t)
+					found++
+				}
+			}
+			for _, entry := range entries {
+				if matches(terms, entry.Message) {
+					fmt.Printf("[%s] %s %s\n", w.Name, entry.Date().Format("2006-01-02"), strings.TrimSpace(entry.Message))
+					found++
+				}
+			}
+			return nil
+		})
+		if err == nil && found == 0 {
+			fmt.Println("no matches")
+		}
+		return err
+	},
+}
+
+// tasks lists the active workspace's tasks, a workspace nothing was written
+// to yet has none
+func tasks() ([]task.Task, error) {
+	tasks, err := task.ListTasks(true)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return tasks, err
+}
+
+func entries() ([]journal.Entry, error) {
+	entries, err := journal.GetEntries(false)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return entries, err
+}
+
+func printTask(w workspace.Workspace, t task.Task) {
+	due := ""
+	if t.DueAt != nil {
+		due = fmt.Sprintf(" (due %s)", t.DueAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("[%s] %s: %s%s\n", w.Name, t.Status.AsString(), t.Title(), due)
+}
+
+func matches(terms []string, fields ...string) bool {
+	text := strings.ToLower(strings.Join(fields, " "))
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"noted/workspace"
+)
+
+var storageDir string
+
+func init() {
+	CreateWorkspaceCmd.Flags().StringVar(&storageDir, "storage-dir", "", "directory for the workspace's notes (default is next to the default storage directory)")
+}
+
+var ListWorkspaceCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list workspaces",
+	Long:  "list the configured workspaces and their storage directories, marking the active one",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		current := workspace.Current()
+		for _, w := range workspace.List() {
+			marker := " "
+			if w.Name == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %-12s %s\n", marker, w.Name, w.StorageDir)
+		}
+	},
+}
+
+var UseWorkspaceCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "switch to a workspace",
+	Long:  "make the workspace the one noted uses when --workspace is not given",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := workspace.Use(args[0]); err != nil {
+			return fmt.Errorf("failed to switch workspace: %w", err)
+		}
+		fmt.Printf("using workspace %s\n", args[0])
+		return nil
+	},
+}
+
+var CreateWorkspaceCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create a workspace",
+	Long:  "add a workspace with its own storage directory to the configuration",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		created, err := workspace.Create(args[0], storageDir)
+		if err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+		fmt.Printf("created workspace %s in %s\n", created.Name, created.StorageDir)
+		return nil
+	},
+}
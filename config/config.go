@@ -0,0 +1,231 @@
+package noted
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrUnknownSetting is a key that names no setting of noted
+	ErrUnknownSetting = errors.New("unknown setting")
+	// ErrInvalidValue is a value a setting cannot take
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrFileExists is a configuration file that would be overwritten
+	ErrFileExists = errors.New("configuration file already exists")
+	// ErrNotYAML is a configuration file noted can read but not change
+	ErrNotYAML = errors.New("only YAML configuration files can be changed")
+)
+
+// Config is the typed form of the settings viper collects from the defaults,
+// the configuration file, the environment and flags
+type Config struct {
+	StorageDir          string               `mapstructure:"storageDir"`
+	JournalPrefix       string               `mapstructure:"journalPrefix"`
+	TaskPrefix          string               `mapstructure:"taskPrefix"`
+	TemplatePrefix      string               `mapstructure:"templatePrefix"`
+	CryptSessionTimeout time.Duration        `mapstructure:"cryptSessionTimeout"`
+	Workspace           string               `mapstructure:"workspace"`
+	Workspaces          map[string]Workspace `mapstructure:"workspaces"`
+	Log                 Log                  `mapstructure:"log"`
+	Backup              Backup               `mapstructure:"backup"`
+	Trash               Trash                `mapstructure:"trash"`
+}
+
+type Log struct {
+	Level      string `mapstructure:"level"`
+	Format     string `mapstructure:"format"`
+	File       string `mapstructure:"file"`
+	MaxSize    int    `mapstructure:"maxSize"`
+	MaxBackups int    `mapstructure:"maxBackups"`
+}
+
+type Backup struct {
+	Keep     int           `mapstructure:"keep"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+type Trash struct {
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+type Workspace struct {
+	StorageDir string `mapstructure:"storageDir"`
+}
+
+// Keys are the settings in the order the default configuration file lists
+// them, each overridable with its environment variable
+var Keys = []string{
+	ConfigStorageDir, ConfigJournalPrefix, ConfigTaskPrefix, ConfigTemplatePrefix, ConfigCryptSessionTimeout,
+	ConfigLogLevel, ConfigLogFormat, ConfigLogFile, ConfigLogMaxSize, ConfigLogMaxBackups,
+	ConfigBackupKeep, ConfigBackupInterval, ConfigTrashRetention, ConfigWorkspace,
+}
+
+// EnvironmentVariable is the variable overriding key, NOTED_STORAGE_DIR for
+// storageDir and NOTED_LOG_MAX_SIZE for log.maxSize
+func EnvironmentVariable(key string) string {
+	var name strings.Builder
+	name.WriteString("NOTED_")
+	for _, r := range key {
+		switch {
+		case r == '.':
+			name.WriteRune('_')
+		case unicode.IsUpper(r):
+			name.WriteRune('_')
+			name.WriteRune(r)
+		default:
+			name.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return name.String()
+}
+
+// SetDefaults registers the default settings and environment overrides
+func SetDefaults(home string) {
+	viper.SetDefault(ConfigStorageDir, path.Join(home, ".noted"))
+	viper.SetDefault(ConfigJournalPrefix, "journal")
+	viper.SetDefault(ConfigTaskPrefix, "task")
+	viper.SetDefault(ConfigCryptSessionTimeout, "1h")
+	viper.SetDefault(ConfigTemplatePrefix, "templates")
+	viper.SetDefault(ConfigLogLevel, "info")
+	viper.SetDefault(ConfigLogFormat, "console")
+	viper.SetDefault(ConfigLogMaxSize, 10)
+	viper.SetDefault(ConfigLogMaxBackups, 3)
+	viper.SetDefault(ConfigBackupKeep, 10)
+	viper.SetDefault(ConfigBackupInterval, "24h")
+	viper.SetDefault(ConfigTrashRetention, "720h")
+
+	for _, key := range Keys {
+		viper.BindEnv(key, EnvironmentVariable(key))
+	}
+}
+
+func Load() (Config, error) {
+	var config Config
+	if err := viper.Unmarshal(&config); err != nil {
+		return config, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+// Validate reports every problem with the configuration at once
+func (c Config) Validate() error {
+	problems := make([]error, 0)
+
+	if c.StorageDir == "" {
+		problems = append(problems, errors.New("storageDir is empty"))
+	} else if !filepath.IsAbs(c.StorageDir) {
+		problems = append(problems, fmt.Errorf("storageDir %q is not an absolute path", c.StorageDir))
+	}
+
+	prefixes := []struct {
+		key   string
+		value string
+	}{
+		{ConfigJournalPrefix, c.JournalPrefix},
+		{ConfigTaskPrefix, c.TaskPrefix},
+		{ConfigTemplatePrefix, c.TemplatePrefix},
+	}
+	seen := make(map[string]string)
+	for _, prefix := range prefixes {
+		switch {
+		case prefix.value == "":
+			problems = append(problems, fmt.Errorf("%s is empty", prefix.key))
+		case filepath.IsAbs(prefix.value) || strings.Contains(prefix.value, ".."):
+			problems = append(problems, fmt.Errorf("%s %q must be a directory inside storageDir", prefix.key, prefix.value))
+		case seen[path.Clean(prefix.value)] != "":
+			problems = append(problems, fmt.Errorf("%s and %s are both %q", seen[path.Clean(prefix.value)], prefix.key, prefix.value))
+		}
+		seen[path.Clean(prefix.value)] = prefix.key
+	}
+
+	if c.CryptSessionTimeout < 0 {
+		problems = append(problems, fmt.Errorf("%s %s is negative", ConfigCryptSessionTimeout, c.CryptSessionTimeout))
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Errorf("%s: %w", ConfigLogLevel, err))
+	}
+	if c.Log.Format != "console" && c.Log.Format != "json" {
+		problems = append(problems, fmt.Errorf("%s %q is neither console nor json", ConfigLogFormat, c.Log.Format))
+	}
+	if c.Log.MaxSize < 1 {
+		problems = append(problems, fmt.Errorf("%s must be at least 1 megabyte", ConfigLogMaxSize))
+	}
+	if c.Log.MaxBackups < 0 {
+		problems = append(problems, fmt.Errorf("%s is negative", ConfigLogMaxBackups))
+	}
+
+	if c.Backup.Keep < 1 {
+		problems = append(problems, fmt.Errorf("%s must keep at least 1 snapshot", ConfigBackupKeep))
+	}
+	if c.Backup.Interval < 0 {
+		problems = append(problems, fmt.Errorf("%s %s is negative", ConfigBackupInterval, c.Backup.Interval))
+	}
+
+	if c.Trash.Retention < 0 {
+		problems = append(problems, fmt.Errorf("%s %s is negative", ConfigTrashRetention, c.Trash.Retention))
+	}
+
+	for name, workspace := range c.Workspaces {
+		if workspace.StorageDir == "" {
+			problems = append(problems, fmt.Errorf("workspace %s has no storageDir", name))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// DefaultFile is written by `noted config init`
+const DefaultFile = `# noted configuration
+#
+# Every setting can be overridden by an environment variable named after it,
+# such as NOTED_STORAGE_DIR for storageDir.
+
+# where notes are kept, ~ is expanded to the home directory
+storageDir: ~/.noted
+
+# directories inside storageDir for the journal, tasks and templates
+journalPrefix: journal
+taskPrefix: task
+templatePrefix: templates
+
+# how long an unlocked encrypted store stays unlocked, 0 to ask every time
+cryptSessionTimeout: 1h
+
+# logging to a file, by default logs/noted.log inside storageDir. The level
+# is one of debug, info, warn or error, the format console or json. The file
+# is rotated once it reaches maxSize megabytes, keeping maxBackups old ones.
+log:
+  level: info
+  format: console
+  # file: ~/.noted/logs/noted.log
+  maxSize: 10
+  maxBackups: 3
+
+# snapshots of the store, kept in backups inside storageDir. One is taken
+# when the store changes and the last is older than interval, 0 to only take
+# them before deleting, migrating or repairing. Only the newest keep are kept.
+backup:
+  keep: 10
+  interval: 24h
+
+# deleted tasks and journal entries stay in the trash for retention, 720h
+# being 30 days, before they are purged. 0 keeps them until the trash is
+# emptied.
+trash:
+  retention: 720h
+
+# separate note stores, the one to use is chosen with --workspace or
+# ` + "`noted workspace use`" + `
+# workspace: work
+# workspaces:
+#   work:
+#     storageDir: ~/notes/work
+`
@@ -3,3 +3,15 @@ package noted
 const ConfigStorageDir = "storageDir"
 const ConfigJournalPrefix = "journalPrefix"
 const ConfigTaskPrefix = "taskPrefix"
+const ConfigCryptSessionTimeout = "cryptSessionTimeout"
+const ConfigTemplatePrefix = "templatePrefix"
+const ConfigWorkspace = "workspace"
+const ConfigWorkspaces = "workspaces"
+const ConfigLogLevel = "log.level"
+const ConfigLogFormat = "log.format"
+const ConfigLogFile = "log.file"
+const ConfigLogMaxSize = "log.maxSize"
+const ConfigLogMaxBackups = "log.maxBackups"
+const ConfigBackupKeep = "backup.keep"
+const ConfigBackupInterval = "backup.interval"
+const ConfigTrashRetention = "trash.retention"
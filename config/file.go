@@ -0,0 +1,86 @@
+package noted
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path"
+	"strings"
+)
+
+// File is the configuration file in use, or the default location when none
+// was found
+func File() string {
+	if file := viper.ConfigFileUsed(); file != "" {
+		return file
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return ".noted.yaml"
+	}
+	return path.Join(home, ".noted.yaml")
+}
+
+// WriteValue sets the dotted key, such as workspaces.work.storageDir, in the
+// configuration file. Unlike viper.WriteConfig it only touches that key, so
+// defaults and flags are not written out and comments are kept.
+func WriteValue(file string, key string, value string) error {
+	if err := Writable(file); err != nil {
+		return err
+	}
+
+	var document yaml.Node
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	node := document.Content[0]
+	for _, name := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: %s is not a mapping", file, key)
+		}
+		node = child(node, name)
+	}
+	node.Kind, node.Tag, node.Value, node.Content = yaml.ScalarNode, "", value, nil
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(&document); err != nil {
+		return err
+	}
+	return os.WriteFile(file, out.Bytes(), 0644)
+}
+
+// Writable reports whether noted can write file. viper also reads
+// .noted.json or .noted.toml, which are left for editing by hand.
+func Writable(file string) error {
+	if ext := strings.ToLower(path.Ext(file)); ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("%w, edit %s by hand", ErrNotYAML, file)
+	}
+	return nil
+}
+
+// child finds the value of key in a mapping, adding it when it is missing.
+// Keys are matched without regard to case, like viper does.
+func child(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return mapping.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
@@ -0,0 +1,267 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+	config "noted/config"
+	"os"
+	"path"
+)
+
+// every encrypted file starts with this marker followed by the GCM nonce and
+// the sealed contents
+var magic = []byte("noted:aes-256-gcm:1\n")
+
+const headerFile = "crypt.yaml"
+
+var (
+	ErrNotEnabled        = errors.New("encryption is not enabled, run `noted crypt init` first")
+	ErrAlreadyEnabled    = errors.New("encryption is already enabled")
+	ErrWrongPassphrase   = errors.New("wrong passphrase")
+	ErrMalformedCipher   = errors.New("encrypted file is truncated or malformed")
+	ErrPassphraseMissing = errors.New("store is locked, run `noted crypt unlock` or set NOTED_PASSPHRASE")
+)
+
+// Header is persisted in the storage directory and holds everything needed to
+// derive and verify the key besides the passphrase itself
+type Header struct {
+	Version int
+	Salt    []byte `yaml:"-"`
+	// Check is a known plaintext sealed with the key, used to reject wrong passphrases early
+	Check []byte `yaml:"-"`
+	// the binary fields are stored base64 encoded
+	EncodedSalt  string `yaml:"salt"`
+	EncodedCheck string `yaml:"check"`
+}
+
+var checkPlaintext = []byte("noted")
+
+func headerPath() string {
+	return path.Join(viper.GetString(config.ConfigStorageDir), headerFile)
+}
+
+func Enabled() bool {
+	_, err := os.Stat(headerPath())
+	return err == nil
+}
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+func readHeader() (Header, error) {
+	var header Header
+	data, err := os.ReadFile(headerPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return header, ErrNotEnabled
+	} else if err != nil {
+		return header, err
+	}
+	if err = yaml.Unmarshal(data, &header); err != nil {
+		return header, err
+	}
+	if header.Salt, err = base64.StdEncoding.DecodeString(header.EncodedSalt); err != nil {
+		return header, err
+	}
+	header.Check, err = base64.StdEncoding.DecodeString(header.EncodedCheck)
+	return header, err
+}
+
+func writeHeader(header Header) error {
+	header.EncodedSalt = base64.StdEncoding.EncodeToString(header.Salt)
+	header.EncodedCheck = base64.StdEncoding.EncodeToString(header.Check)
+	data, err := yaml.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(headerPath(), data, 0600)
+}
+
+func DeriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func Encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append(append([]byte{}, magic...), nonce...)
+	return gcm.Seal(sealed, nonce, plaintext, nil), nil
+}
+
+func Decrypt(key []byte, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(magic):]
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformedCipher
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func newHeader(passphrase string) (Header, []byte, error) {
+	header := Header{Version: 1, Salt: make([]byte, 16)}
+	if _, err := rand.Read(header.Salt); err != nil {
+		return header, nil, err
+	}
+
+	key, err := DeriveKey(passphrase, header.Salt)
+	if err != nil {
+		return header, nil, err
+	}
+
+	if header.Check, err = Encrypt(key, checkPlaintext); err != nil {
+		return header, nil, err
+	}
+	return header, key, nil
+}
+
+// Init enables encryption for the store and returns the new key. Existing
+// files are left as they are, see storage.Recode.
+func Init(passphrase string) ([]byte, error) {
+	if Enabled() {
+		return nil, ErrAlreadyEnabled
+	}
+
+	header, key, err := newHeader(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = writeHeader(header); err != nil {
+		return nil, err
+	}
+
+	return key, cacheKey(key)
+}
+
+// Verify derives the key for passphrase and checks it against the store
+func Verify(passphrase string) ([]byte, error) {
+	header, err := readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := DeriveKey(passphrase, header.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if check, err := Decrypt(key, header.Check); err != nil || !bytes.Equal(check, checkPlaintext) {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+func Unlock(passphrase string) error {
+	key, err := Verify(passphrase)
+	if err != nil {
+		return err
+	}
+	return cacheKey(key)
+}
+
+// Rekey replaces the store's passphrase. recode is handed the old and the
+// new key to re-encrypt the files; the new header is only written once it
+// succeeded, so a failure leaves the store readable with the old passphrase.
+func Rekey(oldPassphrase string, newPassphrase string, recode func(from []byte, to []byte) error) error {
+	oldKey, err := Verify(oldPassphrase)
+	if err != nil {
+		return err
+	}
+
+	header, newKey, err := newHeader(newPassphrase)
+	if err != nil {
+		return err
+	}
+
+	if err = recode(oldKey, newKey); err != nil {
+		return err
+	}
+
+	if err = writeHeader(header); err != nil {
+		return err
+	}
+
+	return cacheKey(newKey)
+}
+
+func Lock() error {
+	sessionKey = nil
+	name, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Forget drops the key held by this process without ending the session,
+// such as when switching to another store
+func Forget() {
+	sessionKey = nil
+}
+
+// Key returns the key for the store, from this process, the session cache,
+// NOTED_PASSPHRASE or by prompting on the terminal, in that order
+func Key() ([]byte, error) {
+	if sessionKey != nil {
+		return sessionKey, nil
+	}
+
+	if key, err := cachedKey(); err == nil {
+		sessionKey = key
+		return key, nil
+	}
+
+	passphrase, ok := os.LookupEnv("NOTED_PASSPHRASE")
+	if !ok {
+		var err error
+		if passphrase, err = Prompt("passphrase: "); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPassphraseMissing, err)
+		}
+	}
+
+	key, err := Verify(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key, cacheKey(key)
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package crypt
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+func ownedByUser(info fs.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) == os.Getuid()
+}
@@ -0,0 +1,125 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	config "noted/config"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var sessionKey []byte
+
+var errSessionExpired = errors.New("session expired")
+
+// sessionPath is unique per storage directory and lives in the runtime
+// directory when there is one, which is usually memory backed, or else in a
+// private directory of the temporary directory
+func sessionPath() (string, error) {
+	directory, err := sessionDirectory()
+	if err != nil {
+		return "", err
+	}
+	storage := sha256.Sum256([]byte(viper.GetString(config.ConfigStorageDir)))
+	return path.Join(directory, fmt.Sprintf("noted-%d-%s.key", os.Getuid(), hex.EncodeToString(storage[:8]))), nil
+}
+
+// sessionDirectory refuses a temporary directory another user could have
+// created or opened up, as they could read the key or plant one
+func sessionDirectory() (string, error) {
+	if directory := os.Getenv("XDG_RUNTIME_DIR"); directory != "" {
+		return directory, nil
+	}
+
+	directory := path.Join(os.TempDir(), fmt.Sprintf("noted-%d", os.Getuid()))
+	if err := os.Mkdir(directory, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", err
+	}
+	info, err := os.Lstat(directory)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() || info.Mode().Perm() != 0700 || !ownedByUser(info) {
+		return "", fmt.Errorf("%s is not a private directory of the current user, set XDG_RUNTIME_DIR instead", directory)
+	}
+	return directory, nil
+}
+
+func cacheKey(key []byte) error {
+	sessionKey = key
+	timeout := viper.GetDuration(config.ConfigCryptSessionTimeout)
+	if timeout <= 0 {
+		return nil
+	}
+
+	name, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	// a fresh file, never one that is already there or a link
+	if err = os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(timeout).Unix()
+	if _, err = fmt.Fprintf(file, "%s\n%d\n", hex.EncodeToString(key), expires); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func cachedKey() ([]byte, error) {
+	name, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0077 != 0 || !ownedByUser(info) {
+		return nil, fmt.Errorf("%s is not a private file of the current user", name)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return nil, errSessionExpired
+	}
+
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		os.Remove(name)
+		return nil, errSessionExpired
+	}
+
+	return hex.DecodeString(fields[0])
+}
+
+func Prompt(label string) (string, error) {
+	descriptor := int(os.Stdin.Fd())
+	if !term.IsTerminal(descriptor) {
+		return "", errors.New("standard input is not a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, label)
+	passphrase, err := term.ReadPassword(descriptor)
+	fmt.Fprintln(os.Stderr)
+	return string(passphrase), err
+}
@@ -0,0 +1,42 @@
+// Package editor lets the user change text in their $EDITOR
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Edit opens contents in $EDITOR, falling back to vi, and returns the text
+// once the editor exits. pattern names the temporary file as in
+// os.CreateTemp, so editors can pick up the file type from its extension.
+func Edit(contents []byte, pattern string) ([]byte, error) {
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(contents); err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// editors such as "code --wait" carry their own arguments
+	command := strings.Fields(editor)
+	process := exec.Command(command[0], append(command[1:], file.Name())...)
+	process.Stdin = os.Stdin
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
+	if err = process.Run(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(file.Name())
+}
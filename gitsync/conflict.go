@@ -0,0 +1,72 @@
+package gitsync
+
+import (
+	"noted/storage"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// historyDepth is how many committed versions of a file ConflictBase weighs
+const historyDepth = 100
+
+// ConflictBase is a storage.BaseFinder for stores kept in git, where a file
+// sync tool left a conflict copy of file. The other machine's history is not
+// at hand, so the base is the committed version of the file closest to the
+// conflict copy, the newest one on a tie: the last version both machines
+// shared before the copy drifted away from it.
+func ConflictBase(file string, theirs []byte) ([]byte, bool, error) {
+	if !Enabled() {
+		return nil, false, nil
+	}
+	relative, err := filepath.Rel(storageDir(), file)
+	if err != nil {
+		return nil, false, err
+	}
+	relative = filepath.ToSlash(relative)
+
+	out, err := git("log", "--format=%H", "-n", strconv.Itoa(historyDepth), "--", relative)
+	if err != nil {
+		// a repository without commits yet
+		return nil, false, nil
+	}
+
+	theirLines := lineSet(theirs)
+	var base []byte
+	found := false
+	closest := 0
+	for _, revision := range strings.Fields(out) {
+		data, err := storage.Decode(show(revision, relative))
+		if err != nil {
+			return nil, false, err
+		}
+		if distance := difference(lineSet(data), theirLines); !found || distance < closest {
+			base, found, closest = data, true, distance
+		}
+	}
+	return base, found, nil
+}
+
+func lineSet(data []byte) map[string]bool {
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		lines[line] = true
+	}
+	return lines
+}
+
+// difference counts the lines in only one of a and b
+func difference(a map[string]bool, b map[string]bool) int {
+	count := 0
+	for line := range a {
+		if !b[line] {
+			count++
+		}
+	}
+	for line := range b {
+		if !a[line] {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,377 @@
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"noted/backup"
+	config "noted/config"
+	"noted/journal"
+	"noted/logging"
+	"noted/storage"
+	"noted/task"
+	"noted/trash"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const Remote = "origin"
+
+// the well known id of git's empty tree
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+var ErrNotInitialized = errors.New("storage directory is not a git repository, run `noted sync init` first")
+
+type GitError struct {
+	Args   []string
+	Output string
+	Err    error
+}
+
+func (g GitError) Error() string {
+	return fmt.Sprintf("git %s: %s: %s", strings.Join(g.Args, " "), g.Err, strings.TrimSpace(g.Output))
+}
+
+func (g GitError) Unwrap() error {
+	return g.Err
+}
+
+// ConflictError is a pull that changed files on both sides which noted
+// cannot merge by itself, the pull being undone
+type ConflictError struct {
+	Files []string
+}
+
+func (c ConflictError) Error() string {
+	return fmt.Sprintf("cannot merge %s changed on both sides, merge them by hand with git", strings.Join(c.Files, ", "))
+}
+
+func storageDir() string {
+	return viper.GetString(config.ConfigStorageDir)
+}
+
+func git(args ...string) (string, error) {
+	command := exec.Command("git", args...)
+	command.Dir = storageDir()
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		return stdout.String(), GitError{Args: args, Output: stderr.String() + stdout.String(), Err: err}
+	}
+	return stdout.String(), nil
+}
+
+func Enabled() bool {
+	_, err := os.Stat(path.Join(storageDir(), ".git"))
+	return err == nil
+}
+
+// Init turns the storage directory into a git repository and records the
+// current state of the store as the first commit. remote may be empty.
+func Init(remote string) error {
+	if !Enabled() {
+		if _, err := git("init"); err != nil {
+			return err
+		}
+	}
+
+	// commits are made on the user's behalf, so do not fail when git has no identity
+	if out, _ := git("config", "user.email"); strings.TrimSpace(out) == "" {
+		if _, err := git("config", "user.email", "noted@localhost"); err != nil {
+			return err
+		}
+		if _, err := git("config", "user.name", "noted"); err != nil {
+			return err
+		}
+	}
+
+	if remote != "" {
+		if _, err := git("remote", "get-url", Remote); err == nil {
+			_, err = git("remote", "set-url", Remote, remote)
+			if err != nil {
+				return err
+			}
+		} else if _, err = git("remote", "add", Remote, remote); err != nil {
+			return err
+		}
+	}
+
+	return Commit("initialize note store")
+}
+
+// Commit records every pending change in the storage directory. It is a no-op
+// when nothing changed.
+func Commit(message string) error {
+	if !Enabled() {
+		return ErrNotInitialized
+	}
+
+	if err := excludeLocal(); err != nil {
+		return err
+	}
+	if _, err := git("add", "-A"); err != nil {
+		return err
+	}
+
+	if out, err := git("status", "--porcelain"); err != nil {
+		return err
+	} else if strings.TrimSpace(out) == "" {
+		return nil
+	}
+
+	_, err := git("commit", "-q", "-m", message)
+	return err
+}
+
+// excludeLocal keeps what only matters to this machine out of the
+// repository: the backups, and a log file inside the storage directory with
+// its rotated copies. The exclusion is local to this clone, as every machine
+// decides for itself where to log.
+func excludeLocal() error {
+	patterns := []string{"/" + backup.Prefix + "/"}
+	if logging.File() != "" {
+		relative, err := filepath.Rel(storageDir(), logging.File())
+		if err == nil && !strings.HasPrefix(relative, "..") {
+			patterns = append(patterns, "/"+filepath.ToSlash(relative)+"*")
+		}
+	}
+
+	exclude := path.Join(storageDir(), ".git", "info", "exclude")
+	contents, err := os.ReadFile(exclude)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	lines := strings.Split(string(contents), "\n")
+	missing := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if !slices.Contains(lines, pattern) {
+			missing = append(missing, pattern+"\n")
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err = os.MkdirAll(path.Dir(exclude), 0755); err != nil {
+		return err
+	}
+	if len(contents) > 0 && !bytes.HasSuffix(contents, []byte("\n")) {
+		contents = append(contents, '\n')
+	}
+	return os.WriteFile(exclude, append(contents, []byte(strings.Join(missing, ""))...), 0644)
+}
+
+// AutoCommit is a storage.ChangeHook committing each mutation of the store
+// when it is under version control
+func AutoCommit(description string) {
+	if !Enabled() {
+		return
+	}
+	if err := Commit(description); err != nil {
+		logging.Logger.Error("failed to commit change", zap.String("change", description), zap.Error(err))
+	}
+}
+
+func branch() (string, error) {
+	// symbolic-ref also works before the first commit
+	out, err := git("symbolic-ref", "--short", "HEAD")
+	return strings.TrimSpace(out), err
+}
+
+func Push() error {
+	if !Enabled() {
+		return ErrNotInitialized
+	}
+
+	current, err := branch()
+	if err != nil {
+		return err
+	}
+
+	_, err = git("push", "-u", Remote, current)
+	return err
+}
+
+// Pull fetches the remote and merges it into the local store. Files changed on
+// both sides are merged semantically: tasks by their Id and journals by line,
+// so concurrent edits on different machines do not produce conflict markers.
+func Pull() error {
+	if !Enabled() {
+		return ErrNotInitialized
+	}
+
+	current, err := branch()
+	if err != nil {
+		return err
+	}
+
+	if _, err = git("fetch", Remote); err != nil {
+		return err
+	}
+
+	upstream := fmt.Sprintf("%s/%s", Remote, current)
+	if _, err = git("rev-parse", "--verify", "-q", upstream); err != nil {
+		logging.Logger.Debug("remote branch does not exist yet", zap.String("branch", upstream))
+		return nil
+	}
+
+	if _, err = git("rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		// nothing committed locally yet
+		_, err = git("merge", "-q", "--ff-only", upstream)
+		return err
+	}
+
+	// a store initialized separately on each machine shares no history with
+	// the remote, in which case everything is merged against an empty tree
+	base, err := git("merge-base", "HEAD", upstream)
+	if err != nil {
+		base = emptyTree
+	}
+	base = strings.TrimSpace(base)
+
+	head, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(head) == base {
+		_, err = git("merge", "-q", "--ff-only", upstream)
+		return err
+	}
+
+	ourChanges, err := changedFiles(base, "HEAD")
+	if err != nil {
+		return err
+	}
+	theirChanges, err := changedFiles(base, upstream)
+	if err != nil {
+		return err
+	}
+
+	// a failing merge is expected when both sides touched the same lines,
+	// those files are resolved below. Any other failure, such as local
+	// changes the merge would overwrite, leaves no merge in progress.
+	if _, err = git("merge", "--no-commit", "--no-ff", "--allow-unrelated-histories", upstream); err != nil {
+		if !merging() {
+			git("merge", "--abort")
+			return err
+		}
+		logging.Logger.Debug("merge reported conflicts", zap.Error(err))
+	}
+
+	for file := range ourChanges {
+		if !theirChanges[file] {
+			continue
+		}
+		if err = mergeFile(file, base, upstream); err != nil {
+			git("merge", "--abort")
+			return err
+		}
+	}
+
+	if !merging() {
+		// fast-forward or nothing to merge
+		return nil
+	}
+
+	// conflicts in files noted does not know how to merge
+	unmerged, err := git("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		git("merge", "--abort")
+		return err
+	}
+	if files := strings.Split(strings.TrimSpace(unmerged), "\n"); files[0] != "" {
+		git("merge", "--abort")
+		return ConflictError{Files: files}
+	}
+
+	_, err = git("commit", "-q", "-m", fmt.Sprintf("merge %s", upstream))
+	return err
+}
+
+// merging reports whether a merge is in progress
+func merging() bool {
+	_, err := os.Stat(path.Join(storageDir(), ".git", "MERGE_HEAD"))
+	return err == nil
+}
+
+func changedFiles(from string, to string) (map[string]bool, error) {
+	out, err := git("diff", "--name-only", from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	for _, file := range strings.Split(out, "\n") {
+		if file = strings.TrimSpace(file); file != "" {
+			files[file] = true
+		}
+	}
+	return files, nil
+}
+
+func show(revision string, file string) []byte {
+	// a missing file on one side merges as an empty one
+	out, _ := git("show", fmt.Sprintf("%s:%s", revision, file))
+	return []byte(out)
+}
+
+func mergeFile(file string, base string, upstream string) error {
+	var merged []byte
+	var err error
+	versions := make([][]byte, 3)
+	for i, revision := range []string{base, "HEAD", upstream} {
+		if versions[i], err = storage.Decode(show(revision, file)); err != nil {
+			return fmt.Errorf("failed to decode %s at %s: %w", file, revision, err)
+		}
+	}
+	baseData, ourData, theirData := versions[0], versions[1], versions[2]
+
+	switch directory := filepath.Dir(file); {
+	case directory == viper.GetString(config.ConfigTaskPrefix) && filepath.Ext(file) == ".yaml":
+		if merged, err = task.MergeFiles(baseData, ourData, theirData); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", file, err)
+		}
+	case directory == viper.GetString(config.ConfigJournalPrefix) && filepath.Ext(file) == ".md":
+		if merged, err = journal.MergeFiles(baseData, ourData, theirData); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", file, err)
+		}
+	case filepath.ToSlash(file) == trash.Name:
+		if merged, err = trash.MergeFiles(baseData, ourData, theirData); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", file, err)
+		}
+	default:
+		// leave anything else to git
+		return nil
+	}
+
+	logging.Logger.Debug("merged file", zap.String("file", file))
+	if err = storage.WriteFile(path.Join(storageDir(), file), merged); err != nil {
+		return err
+	}
+	_, err = git("add", file)
+	return err
+}
+
+func Status() (string, error) {
+	if !Enabled() {
+		return "", ErrNotInitialized
+	}
+
+	status, err := git("status", "--short", "--branch")
+	if err != nil {
+		return "", err
+	}
+
+	if last, err := git("log", "-1", "--format=last change: %s (%cr)"); err == nil {
+		status += last
+	}
+
+	return status, nil
+}
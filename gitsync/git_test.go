@@ -0,0 +1,357 @@
+package gitsync
+
+import (
+	"errors"
+	"github.com/spf13/viper"
+	config "noted/config"
+	"noted/storage"
+	"noted/task"
+	"noted/trash"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	out, err := command.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newStores clones an empty bare repository, standing in for the remote,
+// into two stores
+func newStores(t *testing.T) (string, string) {
+	t.Helper()
+	remote := t.TempDir()
+	run(t, remote, "init", "-q", "--bare")
+
+	stores := make([]string, 2)
+	for i := range stores {
+		stores[i] = t.TempDir()
+		run(t, stores[i], "clone", "-q", remote, ".")
+		run(t, stores[i], "config", "user.email", "noted@localhost")
+		run(t, stores[i], "config", "user.name", "noted")
+		if err := os.MkdirAll(path.Join(stores[i], "task"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.Set(config.ConfigTaskPrefix, "task")
+	viper.Set(config.ConfigJournalPrefix, "journal")
+	return stores[0], stores[1]
+}
+
+func use(store string) {
+	viper.Set(config.ConfigStorageDir, store)
+}
+
+func commit(t *testing.T, message string) {
+	t.Helper()
+	if err := Commit(message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func push(t *testing.T) {
+	t.Helper()
+	if err := Push(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pull(t *testing.T) {
+	t.Helper()
+	if err := Pull(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func addTask(t *testing.T, title string) task.Task {
+	t.Helper()
+	created, err := task.AddTask(task.Task{Task: title, CreatedAt: time.Date(2023, time.September, 1, 9, 0, 0, 0, time.Local)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func findTask(t *testing.T, id string) task.Task {
+	t.Helper()
+	found, err := task.FindTask(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func TestPushPull(t *testing.T) {
+	ours, theirs := newStores(t)
+
+	use(ours)
+	created := addTask(t, "buy milk")
+	commit(t, "add task")
+	push(t)
+
+	use(theirs)
+	pull(t)
+	if found := findTask(t, created.Id); found.Task != "buy milk" {
+		t.Errorf("pulled task is %q, want %q", found.Task, "buy milk")
+	}
+}
+
+func TestPullFastForward(t *testing.T) {
+	ours, theirs := newStores(t)
+
+	use(ours)
+	addTask(t, "first")
+	commit(t, "add first")
+	push(t)
+	use(theirs)
+	pull(t)
+
+	use(ours)
+	second := addTask(t, "second")
+	commit(t, "add second")
+	push(t)
+	head := run(t, ours, "rev-parse", "HEAD")
+
+	use(theirs)
+	pull(t)
+	if got := run(t, theirs, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD is %s after pulling, want %s fast-forwarded", got, head)
+	}
+	findTask(t, second.Id)
+}
+
+func TestPullMergesTaskFile(t *testing.T) {
+	ours, theirs := newStores(t)
+
+	use(ours)
+	created := addTask(t, "write report")
+	commit(t, "add task")
+	push(t)
+	use(theirs)
+	pull(t)
+
+	// both sides edit the same task in the same file
+	use(ours)
+	edited := findTask(t, created.Id)
+	edited.Status = task.Done
+	if err := task.UpdateTask(edited); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "finish task")
+	push(t)
+
+	use(theirs)
+	edited = findTask(t, created.Id)
+	edited.Detail = "quarterly numbers"
+	if err := task.UpdateTask(edited); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "describe task")
+	pull(t)
+
+	merged := findTask(t, created.Id)
+	if merged.Status != task.Done || merged.Detail != "quarterly numbers" {
+		t.Errorf("merged task has status %s and detail %q, want both edits", merged.Status.AsString(), merged.Detail)
+	}
+	if merging() {
+		t.Error("merge still in progress after pulling")
+	}
+	push(t)
+}
+
+func TestPullMergesTrash(t *testing.T) {
+	ours, theirs := newStores(t)
+
+	use(ours)
+	first := addTask(t, "first")
+	second := addTask(t, "second")
+	commit(t, "add tasks")
+	push(t)
+	use(theirs)
+	pull(t)
+
+	// each side deletes another task, adding to the trash on both sides
+	use(ours)
+	if err := task.DeleteTask(findTask(t, first.Id)); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "delete first")
+	push(t)
+
+	use(theirs)
+	if err := task.DeleteTask(findTask(t, second.Id)); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "delete second")
+	pull(t)
+
+	items, err := trash.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	slices.Sort(titles)
+	if strings.Join(titles, " ") != "first second" {
+		t.Errorf("trash holds %v after pulling, want both deleted tasks", titles)
+	}
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("%d tasks left after both were deleted", len(tasks))
+	}
+}
+
+func TestPullDirtyTree(t *testing.T) {
+	ours, theirs := newStores(t)
+
+	use(ours)
+	created := addTask(t, "call plumber")
+	commit(t, "add task")
+	push(t)
+	use(theirs)
+	pull(t)
+
+	use(ours)
+	edited := findTask(t, created.Id)
+	edited.Status = task.InProgress
+	if err := task.UpdateTask(edited); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "start task")
+	push(t)
+
+	// a commit of their own, so the pull merges rather than fast-forwards,
+	// and an uncommitted change the merge would overwrite
+	use(theirs)
+	if err := os.WriteFile(path.Join(theirs, "notes.txt"), []byte("theirs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "add notes")
+	before := run(t, theirs, "rev-parse", "HEAD")
+	edited = findTask(t, created.Id)
+	edited.Detail = "not committed"
+	if err := task.UpdateTask(edited); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pull(); err == nil {
+		t.Fatal("pull into a dirty store succeeded")
+	}
+	if merging() {
+		t.Error("failed pull left a merge in progress")
+	}
+	if got := run(t, theirs, "rev-parse", "HEAD"); got != before {
+		t.Errorf("failed pull moved HEAD to %s", got)
+	}
+	if found := findTask(t, created.Id); found.Detail != "not committed" {
+		t.Errorf("failed pull lost the local change, detail is %q", found.Detail)
+	}
+}
+
+func TestPullUnmergeableFile(t *testing.T) {
+	ours, theirs := newStores(t)
+	write := func(store string, contents string) {
+		if err := os.WriteFile(path.Join(store, "notes.txt"), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	use(ours)
+	write(ours, "shared\n")
+	commit(t, "add notes")
+	push(t)
+	use(theirs)
+	pull(t)
+
+	use(ours)
+	write(ours, "ours\n")
+	commit(t, "edit notes")
+	push(t)
+
+	use(theirs)
+	write(theirs, "theirs\n")
+	commit(t, "edit notes differently")
+
+	var conflict ConflictError
+	if err := Pull(); !errors.As(err, &conflict) {
+		t.Fatalf("pull returned %v, want a ConflictError", err)
+	}
+	if len(conflict.Files) != 1 || conflict.Files[0] != "notes.txt" {
+		t.Errorf("conflicting files are %v, want [notes.txt]", conflict.Files)
+	}
+	if merging() {
+		t.Error("failed pull left a merge in progress")
+	}
+}
+
+func TestConflictBaseFromHistory(t *testing.T) {
+	store, _ := newStores(t)
+	use(store)
+	storage.FindBasesWith(ConflictBase)
+	t.Cleanup(func() { storage.FindBasesWith(nil) })
+
+	deleted := addTask(t, "deleted here")
+	kept := addTask(t, "kept")
+	commit(t, "add tasks")
+	file := path.Join(store, "task", "2023-September.yaml")
+	shared, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the other machine adds a task to the version both had
+	added := addTask(t, "added there")
+	theirs, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(file, shared, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// while this one deletes a task, leaving no tombstone behind
+	if err = task.DeleteTask(findTask(t, deleted.Id)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = trash.Empty(); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, "delete task")
+
+	copyPath := path.Join(store, "task", "2023-September.sync-conflict-20231001-123456-ABCDEFG.yaml")
+	if err = os.WriteFile(copyPath, theirs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = task.ResolveConflict(copyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := task.ListTasks(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		titles = append(titles, t.Task)
+	}
+	slices.Sort(titles)
+	if strings.Join(titles, ", ") != added.Task+", "+kept.Task {
+		t.Errorf("tasks after merging are %v, want %q and %q", titles, added.Task, kept.Task)
+	}
+}
@@ -0,0 +1,17 @@
+package journal
+
+import (
+	"go.uber.org/zap"
+	"noted/logging"
+	"noted/storage"
+)
+
+// ResolveConflict merges the conflict copy at copyPath into its original
+// journal file and removes the copy
+func ResolveConflict(copyPath string) error {
+	if err := storage.ResolveConflict(copyPath, MergeFiles, tombstones); err != nil {
+		logging.Logger.Error("failed to merge conflict copy", zap.String("file", copyPath), zap.Error(err))
+		return err
+	}
+	return nil
+}
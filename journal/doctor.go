@@ -0,0 +1,136 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"noted/storage"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalFileName matches the monthly files SaveJournalEntry writes, such as 2023-September.md
+var journalFileName = regexp.MustCompile(`^(\d{4})-([A-Za-z]+)\.md$`)
+
+// legacyLines match lines close enough to "- Weekday D: message" to be
+// rewritten: an abbreviated or lowercase weekday, another bullet or none, and
+// a bullet without a weekday. Each captures the day and the message.
+var legacyLines = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^\s*[-*+]?\s*(?:mon|tue|wed|thu|fri|sat|sun)[a-z]*\.?,?\s+(\d{1,2})\s*:\s*(.*)$`),
+	regexp.MustCompile(`^\s*[-*+]\s+(\d{1,2}): (.*)$`),
+}
+
+// Check looks for journal files not named after a month and lines that are
+// not "- Weekday D: message". With repair, lines in an older or looser
+// format, or with the wrong weekday, are rewritten; other lines are left for
+// the user to fix.
+func Check(repair bool) ([]storage.Problem, error) {
+	files, err := storage.Files(Directory())
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]storage.Problem, 0)
+	report := func(file string, line int, fixable bool, format string, args ...any) {
+		problems = append(problems, storage.Problem{
+			File:        file,
+			Line:        line,
+			Description: fmt.Sprintf(format, args...),
+			Fixable:     fixable,
+		})
+	}
+
+	fixed := 0
+	for _, file := range files {
+		name := path.Base(file)
+		if storage.IsConflictCopy(name) {
+			report(file, 0, false, "conflict copy, run `noted doctor merge-conflicts` to merge it")
+			continue
+		}
+		match := journalFileName.FindStringSubmatch(name)
+		var month time.Time
+		if match != nil {
+			month, err = time.Parse("2006 January", match[1]+" "+match[2])
+		}
+		if match == nil || err != nil {
+			report(file, 0, false, "not named after a month, like 2023-September.md")
+			continue
+		}
+
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return problems, err
+		}
+
+		lines, first, err := decodeFile(file, data)
+		if err != nil {
+			report(file, 0, false, "%s", errors.Unwrap(err))
+			continue
+		}
+
+		var contents bytes.Buffer
+		contents.WriteString(headerLine(Format.Current()) + "\n")
+		changed := false
+		for i, text := range lines {
+			rewritten := checkLine(month, text, func(fixable bool, format string, args ...any) {
+				report(file, first+i, fixable, format, args...)
+			})
+			if rewritten != text {
+				changed = true
+				fixed++
+			}
+			contents.WriteString(rewritten)
+			contents.WriteRune('\n')
+		}
+
+		if repair && changed {
+			if err = storage.WriteFile(file, contents.Bytes()); err != nil {
+				return problems, err
+			}
+		}
+	}
+
+	if repair && fixed > 0 {
+		storage.Changed(fmt.Sprintf("repair %d journal lines", fixed))
+	}
+	return problems, nil
+}
+
+// checkLine returns the line as formatLine would write it, or unchanged when
+// it is fine or cannot be understood, reporting any problem
+func checkLine(month time.Time, text string, report func(fixable bool, format string, args ...any)) string {
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+
+	day, message, ok := parseLine(text)
+	for _, legacy := range legacyLines {
+		if ok {
+			break
+		}
+		if match := legacy.FindStringSubmatch(text); match != nil {
+			day, _ = strconv.Atoi(match[1])
+			message, ok = match[2], true
+		}
+	}
+	if !ok {
+		report(false, "malformed line, %s", errMalformedLine)
+		return text
+	}
+
+	date := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.Local)
+	if day < 1 || date.Month() != month.Month() {
+		report(false, "%s has no day %d", month.Format("January 2006"), day)
+		return text
+	}
+
+	canonical := formatLine(date, strings.TrimSpace(message))
+	if strings.TrimRight(text, " \t") == strings.TrimRight(canonical, " ") {
+		return text
+	}
+	report(true, "legacy line, should be %q", canonical)
+	return canonical
+}
@@ -1,13 +1,14 @@
 package journal
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"noted/config"
 	"noted/logging"
+	"noted/storage"
 	"os"
 	"path"
 	"slices"
@@ -35,68 +36,174 @@ func (e Entry) FilterValue() string {
 	return e.Message
 }
 
+func (e Entry) Date() time.Time {
+	month, err := time.Parse("January", e.Month)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Date(e.Year, month.Month(), e.Day, 0, 0, 0, 0, time.Local)
+}
+
 func SaveJournalEntry(datetime time.Time, entry string) error {
-	prefix := viper.GetString(noted.ConfigJournalPrefix)
-	storageDir := viper.GetString(noted.ConfigStorageDir)
-	journalPath := path.Join(storageDir, prefix)
-	journalFilePath := path.Join(storageDir, prefix, fmt.Sprintf("%d-%s.md", datetime.Year(), datetime.Month()))
-	var journalFile *os.File
-	var err error
+	journalPath := Directory()
+	journalFilePath := path.Join(journalPath, fmt.Sprintf("%d-%s.md", datetime.Year(), datetime.Month()))
 
 	if _, err := os.Stat(journalPath); errors.Is(err, os.ErrNotExist) {
 		if err = os.MkdirAll(journalPath, 0755); err != nil {
 			logging.Logger.Error("failed to create journal path", zap.Error(err))
 			return err
 		}
-		journalFile, err = os.Create(journalFilePath)
-	} else {
-		journalFile, err = os.OpenFile(journalFilePath, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
 	}
-	if err != nil {
-		logging.Logger.Error("failed to create/open journal file", zap.Error(err))
+
+	line := formatLine(datetime, entry) + "\n"
+	if _, err := os.Stat(journalFilePath); errors.Is(err, os.ErrNotExist) {
+		line = headerLine(Format.Current()) + "\n" + line
+	}
+
+	if err := storage.AppendFile(journalFilePath, []byte(line)); err != nil {
+		logging.Logger.Error("failed to append to journal", zap.String("file", journalFilePath), zap.Error(err))
 		return err
 	}
 
-	defer journalFile.Close()
+	storage.Changed(fmt.Sprintf("add journal entry for %s", datetime.Format("2006-01-02")))
+	return nil
+}
 
-	_, _, day := datetime.Date()
-	_, err = journalFile.WriteString(fmt.Sprintf("- %s %d: %s\n", datetime.Weekday(), day, entry))
+// AddEntries stores entries for any date, such as imported ones. Unlike
+// SaveJournalEntry, which appends, every month file touched is kept in day
+// order, and entries that already exist are skipped. It returns how many
+// entries were added.
+func AddEntries(entries []Entry) (int, error) {
+	added, err := addEntries(entries)
+	if added > 0 {
+		storage.Changed(fmt.Sprintf("add %d journal entries", added))
+	}
+	return added, err
+}
 
-	journalFile.Sync()
+func addEntries(entries []Entry) (int, error) {
+	journalPath := Directory()
+	if err := os.MkdirAll(journalPath, 0755); err != nil {
+		logging.Logger.Error("failed to create journal path", zap.Error(err))
+		return 0, err
+	}
 
-	if err != nil {
-		logging.Logger.Error("failed to append to journal", zap.String("file", journalFilePath), zap.Error(err))
+	byFile := make(map[string][]Entry)
+	for _, entry := range entries {
+		date := entry.Date()
+		if date.IsZero() {
+			return 0, fmt.Errorf("invalid journal date %d %s %d", entry.Year, entry.Month, entry.Day)
+		}
+		file := path.Join(journalPath, fmt.Sprintf("%d-%s.md", date.Year(), date.Month()))
+		byFile[file] = append(byFile[file], entry)
 	}
 
-	return err
+	added := 0
+	for file, fileEntries := range byFile {
+		data, err := storage.ReadFile(file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logging.Logger.Error("failed to read journal", zap.String("file", file), zap.Error(err))
+			return added, err
+		}
+
+		if data, err = upgrade(data); err != nil {
+			return added, storage.ParseError{File: file, Err: err}
+		}
+		lines := splitLines(data)
+		existing := lineSet(data)
+		for _, entry := range fileEntries {
+			line := formatLine(entry.Date(), strings.TrimSpace(entry.Message))
+			if !existing[line] {
+				existing[line] = true
+				lines = append(lines, line)
+				added++
+			}
+		}
+		slices.SortStableFunc(lines, func(a string, b string) int {
+			return lineDay(a) - lineDay(b)
+		})
+
+		var contents bytes.Buffer
+		for _, line := range lines {
+			contents.WriteString(line)
+			contents.WriteRune('\n')
+		}
+		if err = storage.WriteFile(file, contents.Bytes()); err != nil {
+			logging.Logger.Error("failed to write journal", zap.String("file", file), zap.Error(err))
+			return added, err
+		}
+	}
+
+	return added, nil
 }
 
-func GetEntries(sortOldestAscending bool) []Entry {
-	prefix := viper.GetString(noted.ConfigJournalPrefix)
-	storageDir := viper.GetString(noted.ConfigStorageDir)
-	journalPath := path.Join(storageDir, prefix)
+func formatLine(datetime time.Time, entry string) string {
+	return fmt.Sprintf("- %s %d: %s", datetime.Weekday(), datetime.Day(), entry)
+}
+
+// lineDay reads the day of the month from a journal line
+func lineDay(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0
+	}
+	day, _ := strconv.Atoi(strings.TrimSuffix(fields[2], ":"))
+	return day
+}
+
+func Directory() string {
+	return path.Join(viper.GetString(noted.ConfigStorageDir), viper.GetString(noted.ConfigJournalPrefix))
+}
+
+// errMalformedLine describes journal lines that are not "- Weekday D: message"
+var errMalformedLine = errors.New(`expected "- Weekday D: message"`)
+
+// GetEntries reads every journal file. Like task.ListTasks, files and lines
+// that cannot be read are reported together in the error, next to the
+// entries that could be.
+func GetEntries(sortOldestAscending bool) ([]Entry, error) {
+	journalPath := Directory()
 	files, err := os.ReadDir(journalPath)
 	if err != nil {
-		logging.Logger.Fatal("failed to list journal files", zap.Error(err), zap.String("directory", journalPath))
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
 	}
 
 	entries := make([]Entry, 0)
+	problems := make([]error, 0)
 
 	for _, file := range files {
-		if fileHandle, err := os.Open(path.Join(journalPath, file.Name())); err != nil {
-			logging.Logger.Error("failed to read file", zap.String("file", path.Join(journalPath, file.Name())), zap.Error(err))
+		if file.IsDir() {
+			continue
+		}
+		if storage.IsConflictCopy(file.Name()) {
+			logging.Logger.Warn("skipping conflict copy, run `noted doctor merge-conflicts` to merge it", zap.String("file", file.Name()))
+			continue
+		}
+		filePath := path.Join(journalPath, file.Name())
+		if data, err := storage.ReadFile(filePath); err != nil {
+			problems = append(problems, err)
 		} else {
-			defer fileHandle.Close()
 			fileElements := strings.Split(file.Name(), "-")
-			year, _ := strconv.Atoi(fileElements[0])
+			year, err := strconv.Atoi(fileElements[0])
+			if err != nil || len(fileElements) < 2 {
+				problems = append(problems, storage.ParseError{File: filePath, Err: errors.New("expected a name like 2023-September.md")})
+				continue
+			}
 			month := strings.Split(fileElements[1], ".")[0]
-			scanner := bufio.NewScanner(fileHandle)
-			for scanner.Scan() {
-				text := scanner.Text()[3:]
-				itemElements := strings.Split(text, ":")
-				dateElements := strings.Split(itemElements[0], " ")
-				day, _ := strconv.Atoi(dateElements[1])
-				message := strings.Join(itemElements[1:], ":")
+			lines, first, err := decodeFile(filePath, data)
+			if err != nil {
+				problems = append(problems, err)
+				continue
+			}
+			for i, text := range lines {
+				if strings.TrimSpace(text) == "" {
+					continue
+				}
+				day, message, ok := parseLine(text)
+				if !ok {
+					problems = append(problems, storage.ParseError{File: filePath, Line: first + i, Err: errMalformedLine})
+					continue
+				}
 				entries = append(entries, Entry{
 					Year:    year,
 					Month:   month,
@@ -111,5 +218,23 @@ func GetEntries(sortOldestAscending bool) []Entry {
 		slices.Reverse(entries)
 	}
 
-	return entries
+	return entries, errors.Join(problems...)
+}
+
+// parseLine splits a journal line into its day and message, the message
+// keeping the space after the colon
+func parseLine(line string) (int, string, bool) {
+	if !strings.HasPrefix(line, "- ") {
+		return 0, "", false
+	}
+	itemElements := strings.Split(line[2:], ":")
+	dateElements := strings.Split(itemElements[0], " ")
+	if len(itemElements) < 2 || len(dateElements) != 2 {
+		return 0, "", false
+	}
+	day, err := strconv.Atoi(dateElements[1])
+	if err != nil {
+		return 0, "", false
+	}
+	return day, strings.Join(itemElements[1:], ":"), true
 }
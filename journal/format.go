@@ -0,0 +1,70 @@
+package journal
+
+import (
+	"bytes"
+	"fmt"
+	"noted/storage"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// header is the first line of a journal file, an HTML comment so it does not
+// show when the Markdown is rendered
+var header = regexp.MustCompile(`^<!-- noted journal v(\d+) -->$`)
+
+func headerLine(version int) string {
+	return fmt.Sprintf("<!-- noted journal v%d -->", version)
+}
+
+// Format is the format of the monthly journal files, the version being
+// recorded in their header
+var Format = storage.Format{
+	Name:      "journal",
+	Directory: Directory,
+	Version: func(data []byte) (int, error) {
+		first, _, _ := bytes.Cut(data, []byte("\n"))
+		if match := header.FindSubmatch(first); match != nil {
+			return strconv.Atoi(string(match[1]))
+		}
+		return 0, nil
+	},
+	Migrations: []storage.Migration{
+		{Description: "add the version header", Apply: func(data []byte) ([]byte, error) {
+			return append([]byte(headerLine(1)+"\n"), data...), nil
+		}},
+	},
+}
+
+func init() {
+	storage.RegisterFormat(Format)
+}
+
+// decodeFile upgrades the contents of the journal file at name to the
+// current format and returns its lines after the header, along with the
+// number the first of them has in the file on disk
+func decodeFile(name string, data []byte) ([]string, int, error) {
+	upgraded, version, err := Format.Upgrade(data)
+	if err != nil {
+		return nil, 0, storage.ParseError{File: name, Err: err}
+	}
+	lines := strings.Split(strings.TrimSuffix(string(upgraded), "\n"), "\n")
+
+	// skip the header, which files written before versions were recorded
+	// only have in memory
+	first := 2
+	if version == 0 {
+		first = 1
+	}
+	if len(lines) > 0 && header.MatchString(lines[0]) {
+		lines = lines[1:]
+	}
+	return lines, first, nil
+}
+
+// upgrade is decodeFile for callers working on whole files, the header being
+// kept as the first line
+func upgrade(data []byte) ([]byte, error) {
+	upgraded, _, err := Format.Upgrade(data)
+	return upgraded, err
+}
@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"bytes"
+)
+
+// MergeFiles combines two divergent versions of a journal file. The journal is
+// append only, so lines added on their side are appended after ours, and
+// lines removed on either side stay removed. Each side is upgraded to the
+// current format first, so the header stays the first line.
+func MergeFiles(base []byte, ours []byte, theirs []byte) ([]byte, error) {
+	for _, side := range []*[]byte{&base, &ours, &theirs} {
+		var err error
+		if *side, err = upgrade(*side); err != nil {
+			return nil, err
+		}
+	}
+
+	baseLines := lineSet(base)
+	ourLines := lineSet(ours)
+	theirLines := lineSet(theirs)
+
+	var merged bytes.Buffer
+	for _, line := range splitLines(ours) {
+		if baseLines[line] && !theirLines[line] {
+			continue
+		}
+		merged.WriteString(line)
+		merged.WriteRune('\n')
+	}
+	for _, line := range splitLines(theirs) {
+		if baseLines[line] || ourLines[line] {
+			continue
+		}
+		merged.WriteString(line)
+		merged.WriteRune('\n')
+	}
+
+	return merged.Bytes(), nil
+}
+
+func splitLines(data []byte) []string {
+	lines := make([]string, 0)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) > 0 {
+			lines = append(lines, string(line))
+		}
+	}
+	return lines
+}
+
+func lineSet(data []byte) map[string]bool {
+	set := make(map[string]bool)
+	for _, line := range splitLines(data) {
+		set[line] = true
+	}
+	return set
+}
@@ -0,0 +1,112 @@
+package journal
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"noted/config"
+	"noted/task"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// DefaultTemplate is used by `noted journal today` unless told otherwise
+const DefaultTemplate = "daily"
+
+// TemplateData is what journal templates are executed with
+type TemplateData struct {
+	Date      time.Time
+	Yesterday time.Time
+	Tomorrow  time.Time
+	// Tasks are the open tasks needing attention on Date
+	Tasks []task.Task
+}
+
+func NewTemplateData(date time.Time, tasks []task.Task) TemplateData {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	data := TemplateData{
+		Date:      day,
+		Yesterday: day.AddDate(0, 0, -1),
+		Tomorrow:  day.AddDate(0, 0, 1),
+	}
+
+	// in progress, or due or scheduled by the end of the day
+	for _, t := range tasks {
+		if !t.Open() {
+			continue
+		}
+		if t.Status == task.InProgress || t.Overdue(data.Tomorrow) || (t.ScheduledFor != nil && t.ScheduledFor.Before(data.Tomorrow)) {
+			data.Tasks = append(data.Tasks, t)
+		}
+	}
+	return data
+}
+
+func TemplateDirectory() string {
+	return path.Join(viper.GetString(noted.ConfigStorageDir), viper.GetString(noted.ConfigTemplatePrefix), "journal")
+}
+
+// RenderTemplate executes the named template from the template directory,
+// falling back to the built in template of that name
+func RenderTemplate(name string, data TemplateData) (string, error) {
+	file := name + ".tmpl"
+	source, err := os.ReadFile(path.Join(TemplateDirectory(), file))
+	if errors.Is(err, os.ErrNotExist) {
+		if source, err = defaultTemplates.ReadFile(path.Join("templates", file)); err != nil {
+			return "", fmt.Errorf("no journal template named %s in %s", name, TemplateDirectory())
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(file).Funcs(template.FuncMap{
+		"format": func(layout string, at any) string {
+			switch at := at.(type) {
+			case time.Time:
+				return at.Format(layout)
+			case *time.Time:
+				if at != nil {
+					return at.Format(layout)
+				}
+			}
+			return ""
+		},
+	}).Parse(string(source))
+	if err != nil {
+		return "", fmt.Errorf("invalid journal template %s: %w", name, err)
+	}
+
+	var rendered bytes.Buffer
+	if err = tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// NoteEntries turns a daily note written from template, the note as it was
+// rendered, into journal messages: one per line, skipping comments starting
+// with # and the template's prompts that were left unanswered
+func NoteEntries(template string, note string) []string {
+	prompts := make(map[string]bool)
+	for _, line := range strings.Split(template, "\n") {
+		prompts[strings.TrimSpace(line)] = true
+	}
+
+	messages := make([]string, 0)
+	for _, line := range strings.Split(note, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || prompts[line] {
+			continue
+		}
+		messages = append(messages, line)
+	}
+	return messages
+}
@@ -0,0 +1,30 @@
+package journal
+
+import (
+	"noted/task"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNoteEntries(t *testing.T) {
+	now := time.Date(2023, time.September, 1, 9, 0, 0, 0, time.Local)
+	rendered, err := RenderTemplate(DefaultTemplate, NewTemplateData(now, []task.Task{{Task: "write report", Status: task.InProgress}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first prompt answered, the others left, and notes of one's own
+	note := strings.Replace(rendered, "Standup:", "Standup: shipped the release", 1) +
+		"Questions for standup:\n" +
+		"  - who reviews the budget\n"
+
+	want := []string{"Standup: shipped the release", "Questions for standup:", "- who reviews the budget"}
+	if entries := NoteEntries(rendered, note); !slices.Equal(entries, want) {
+		t.Errorf("entries are %q, want %q", entries, want)
+	}
+	if entries := NoteEntries(rendered, rendered); len(entries) != 0 {
+		t.Errorf("the note as rendered has entries %q, want none", entries)
+	}
+}
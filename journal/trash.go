@@ -0,0 +1,124 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"noted/backup"
+	"noted/logging"
+	"noted/storage"
+	"noted/trash"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrNotFound = errors.New("journal entry not found")
+
+// TrashKind is the kind of trash items holding deleted journal entries
+const TrashKind = "journal"
+
+func init() {
+	trash.RegisterKind(TrashKind, restore)
+}
+
+// DeleteEntry moves a journal entry from its month file to the trash, after
+// taking a snapshot of the store. Messages are compared without surrounding
+// spaces, and of identical entries only the first goes.
+func DeleteEntry(entry Entry) error {
+	date := entry.Date()
+	if date.IsZero() {
+		return fmt.Errorf("invalid journal date %d %s %d", entry.Year, entry.Month, entry.Day)
+	}
+	file := path.Join(Directory(), fmt.Sprintf("%d-%s.md", date.Year(), date.Month()))
+
+	data, err := storage.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w: %q", file, ErrNotFound, strings.TrimSpace(entry.Message))
+	} else if err != nil {
+		return err
+	}
+	lines, _, err := decodeFile(file, data)
+	if err != nil {
+		return err
+	}
+
+	deleted := -1
+	for i, line := range lines {
+		if day, message, ok := parseLine(line); ok && day == entry.Day && strings.TrimSpace(message) == strings.TrimSpace(entry.Message) {
+			deleted = i
+			break
+		}
+	}
+	if deleted < 0 {
+		return fmt.Errorf("%s: %w: %q", file, ErrNotFound, strings.TrimSpace(entry.Message))
+	}
+
+	if _, err = backup.Create("delete"); err != nil {
+		logging.Logger.Error("failed to snapshot the store", zap.Error(err))
+		return fmt.Errorf("failed to back up before deleting: %w", err)
+	}
+	if _, err = trash.Add(TrashKind, strings.TrimSpace(entry.Message), file, lines[deleted]); err != nil {
+		logging.Logger.Error("failed to move journal entry to the trash", zap.Error(err))
+		return err
+	}
+
+	var contents bytes.Buffer
+	contents.WriteString(headerLine(Format.Current()) + "\n")
+	for i, line := range lines {
+		if i != deleted {
+			contents.WriteString(line)
+			contents.WriteRune('\n')
+		}
+	}
+	if err = storage.WriteFile(file, contents.Bytes()); err != nil {
+		logging.Logger.Error("failed to write journal", zap.String("file", file), zap.Error(err))
+		return err
+	}
+
+	storage.Changed(fmt.Sprintf("delete journal entry for %s", date.Format("2006-01-02")))
+	return nil
+}
+
+// restore puts a deleted line back into its month file, in day order
+func restore(item trash.Item) error {
+	day, message, ok := parseLine(item.Data)
+	if !ok {
+		return fmt.Errorf("invalid journal line in the trash: %w", errMalformedLine)
+	}
+	match := journalFileName.FindStringSubmatch(path.Base(item.File))
+	if match == nil {
+		return fmt.Errorf("%s is not a journal file", item.File)
+	}
+	year, err := strconv.Atoi(match[1])
+	if err != nil {
+		return err
+	}
+
+	added, err := addEntries([]Entry{{Year: year, Month: match[2], Day: day, Message: message}})
+	if err == nil && added == 0 {
+		return fmt.Errorf("journal entry %q exists already", item.Title)
+	}
+	return err
+}
+
+// tombstones is a journal file of the lines in the trash that were deleted
+// from file, standing in for the common ancestor of a conflict copy so the
+// merge keeps them deleted
+func tombstones(file string) ([]byte, error) {
+	items, err := trash.List()
+	if err != nil {
+		return nil, err
+	}
+	var contents bytes.Buffer
+	contents.WriteString(headerLine(Format.Current()) + "\n")
+	for _, item := range items {
+		if item.Kind == TrashKind && item.Path() == file {
+			contents.WriteString(item.Data)
+			contents.WriteRune('\n')
+		}
+	}
+	return contents.Bytes(), nil
+}
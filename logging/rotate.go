@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sync"
+)
+
+// rotatingFile appends to a log file, moving it aside to path.1, path.2 and
+// so on once it reaches maxSize
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(name string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(path.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: name, maxSize: maxSize, maxBackups: maxBackups}
+	return r, r.open()
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	backup := func(i int) string {
+		return fmt.Sprintf("%s.%d", r.path, i)
+	}
+	os.Remove(backup(r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(backup(i), backup(i+1))
+	}
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -1,28 +1,107 @@
 package logging
 
 import (
-	"encoding/json"
+	"fmt"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
 )
 
-var zapConfigJson = []byte(`{
-	  "level": "info",
-	  "encoding": "console",
-	  "outputPaths": ["stdout"],
-	  "errorOutputPaths": ["stderr"],
-	  "encoderConfig": {
-	    "messageKey": "message",
-	    "levelKey": "level",
-	    "levelEncoder": "lowercase"
-	  }
-	}`)
-
-var zapConfig zap.Config
+const (
+	Console = "console"
+	JSON    = "json"
+)
+
+// Options configure where logs go. Everything at Level and above is written
+// to File, while the terminal only gets what the user has to see, at
+// TerminalLevel and above, on stderr so it does not mix with output.
+type Options struct {
+	Level         zapcore.Level
+	TerminalLevel zapcore.Level
+	Format        string
+	File          string
+	// MaxSize is the size in megabytes a log file grows to before it is
+	// rotated, keeping MaxBackups old files
+	MaxSize    int
+	MaxBackups int
+}
+
 var Logger *zap.Logger
 
+// file is the log file in use, if any
+var file *rotatingFile
+
+// fileCore writes to file, nil when there is none
+var fileCore zapcore.Core
+
 func init() {
-	if err := json.Unmarshal(zapConfigJson, &zapConfig); err != nil {
-		panic(err)
+	// until the configuration is read, only warnings and errors are shown
+	Logger = zap.New(terminalCore(zapcore.WarnLevel))
+}
+
+func terminalCore(level zapcore.Level) zapcore.Core {
+	encoderConfig := zapcore.EncoderConfig{
+		MessageKey:  "message",
+		LevelKey:    "level",
+		EncodeLevel: zapcore.LowercaseLevelEncoder,
+	}
+	return zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig), zapcore.Lock(os.Stderr), level)
+}
+
+// Configure replaces Logger. When the log file cannot be opened, logging
+// carries on to the terminal only and the error is returned.
+func Configure(options Options) error {
+	cores := []zapcore.Core{terminalCore(options.TerminalLevel)}
+
+	var err error
+	if file != nil {
+		file.Close()
+		file, fileCore = nil, nil
+	}
+	if options.File != "" {
+		file, err = openRotatingFile(options.File, int64(options.MaxSize)*1024*1024, options.MaxBackups)
+		if err == nil {
+			fileCore = zapcore.NewCore(encoder(options.Format), file, options.Level)
+			cores = append(cores, fileCore)
+		} else {
+			err = fmt.Errorf("failed to open log file: %w", err)
+		}
+	}
+
+	Logger = zap.New(zapcore.NewTee(cores...))
+	return err
+}
+
+// SuspendTerminal keeps log messages off the terminal while a full screen
+// program draws on it, logging to the file only, and returns the function
+// bringing them back
+func SuspendTerminal() func() {
+	previous := Logger
+	if fileCore != nil {
+		Logger = zap.New(fileCore)
+	} else {
+		Logger = zap.NewNop()
+	}
+	return func() {
+		Logger = previous
+	}
+}
+
+// File is the path of the log file in use, empty when logging only to the
+// terminal
+func File() string {
+	if file == nil {
+		return ""
+	}
+	return file.path
+}
+
+func encoder(format string) zapcore.Encoder {
+	config := zap.NewProductionEncoderConfig()
+	config.TimeKey = "time"
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	if format == JSON {
+		return zapcore.NewJSONEncoder(config)
 	}
-	Logger = zap.Must(zapConfig.Build())
+	return zapcore.NewConsoleEncoder(config)
 }
@@ -8,7 +8,5 @@ import (
 
 func main() {
 	logging.Logger.Debug("logger construction succeeded")
-	if err := cmd.RootCmd.Execute(); err != nil {
-		os.Exit(127)
-	}
+	os.Exit(cmd.Execute())
 }
@@ -0,0 +1,356 @@
+package org
+
+import (
+	"fmt"
+	"io"
+	"noted/journal"
+	"noted/task"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the day headings of a datetree, as written by org-capture
+var dayPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:\s+\w+)?$`)
+
+const (
+	idProperty       = "ID"
+	createdProperty  = "CREATED"
+	projectsProperty = "PROJECTS"
+)
+
+// keywords noted writes, one per task.Status
+var exportKeywords = Keywords{
+	Todo: []string{
+		task.Status(task.ToDo).AsString(),
+		task.Status(task.Scheduled).AsString(),
+		task.Status(task.InProgress).AsString(),
+		task.Status(task.Paused).AsString(),
+	},
+	Done: []string{
+		task.Status(task.Done).AsString(),
+		task.Status(task.Cancelled).AsString(),
+	},
+}
+
+// keywords commonly found in Org files that noted has a status for
+var keywordAliases = map[string]task.Status{
+	"NEXT":     task.ToDo,
+	"STARTED":  task.InProgress,
+	"DOING":    task.InProgress,
+	"WAITING":  task.Paused,
+	"HOLD":     task.Paused,
+	"CANCELED": task.Cancelled,
+}
+
+type Result struct {
+	Tasks   task.ImportResult
+	Journal int
+}
+
+// Status maps a TODO keyword to a task status, falling back to whether the
+// document declares it as finished
+func (k Keywords) Status(keyword string) task.Status {
+	if status, err := task.ParseStatus(keyword); err == nil {
+		return status
+	}
+	if status, ok := keywordAliases[strings.ToUpper(keyword)]; ok {
+		return status
+	}
+	if k.isDone(keyword) {
+		return task.Done
+	}
+	return task.ToDo
+}
+
+// Import creates a task for every heading with a TODO keyword, nested tasks
+// becoming subtasks, and a journal entry for every item below a datetree day.
+// Tasks are matched to earlier imports through their ID property, or their
+// title and parent when they have none.
+func Import(doc *Document) (Result, error) {
+	var result Result
+	importer, err := task.NewImporter()
+	if err != nil {
+		return result, err
+	}
+	entries := make([]journal.Entry, 0)
+
+	// parent is the task headings are nested in, identified by id for
+	// storage and by uid for headings without an ID property of their own
+	type parentTask struct{ id, uid string }
+
+	var walk func(headings []*Heading, parent parentTask, day *time.Time) error
+	walk = func(headings []*Heading, parent parentTask, day *time.Time) error {
+		for _, heading := range headings {
+			switch {
+			case heading.Keyword != "":
+				uid := heading.Properties[idProperty]
+				if uid == "" {
+					// stable as long as the heading keeps its title and place
+					uid = fmt.Sprintf("org:%s/%s", parent.uid, heading.Title)
+				}
+				imported, err := importer.Import(uid, func(t *task.Task) error {
+					return doc.apply(heading, parent.id, t)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to import %q: %w", heading.Title, err)
+				}
+				if err = walk(heading.Children, parentTask{imported.Id, uid}, nil); err != nil {
+					return err
+				}
+			case day != nil:
+				message := heading.Title
+				if len(heading.Body) > 0 {
+					message = fmt.Sprintf("%s: %s", message, strings.Join(nonEmpty(heading.Body), " "))
+				}
+				entries = append(entries, journalEntry(*day, message))
+				if err := walk(heading.Children, parent, day); err != nil {
+					return err
+				}
+			case dayPattern.MatchString(heading.Title):
+				date, err := time.ParseInLocation("2006-01-02", dayPattern.FindStringSubmatch(heading.Title)[1], time.Local)
+				if err != nil {
+					return err
+				}
+				for _, item := range items(heading.Body) {
+					entries = append(entries, journalEntry(date, item))
+				}
+				if err = walk(heading.Children, parent, &date); err != nil {
+					return err
+				}
+			default:
+				// plain headings only group what is below them
+				if err := walk(heading.Children, parent, nil); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	err = walk(doc.Headings, parentTask{}, nil)
+	result.Tasks = importer.Result
+	if err != nil {
+		return result, err
+	}
+
+	result.Journal, err = journal.AddEntries(entries)
+	return result, err
+}
+
+func (d *Document) apply(heading *Heading, parent string, t *task.Task) error {
+	if created, ok := heading.Properties[createdProperty]; ok && t.CreatedAt.IsZero() {
+		at, err := parseTimestamp(created)
+		if err != nil {
+			return err
+		}
+		t.CreatedAt = *at
+	}
+
+	t.Task = heading.Title
+	t.Detail = strings.Join(heading.Body, "\n")
+	t.Status = d.Keywords.Status(heading.Keyword)
+	t.Priority = heading.Priority
+	t.DueAt = heading.Deadline
+	t.ScheduledFor = heading.Scheduled
+	t.Contexts = heading.Tags
+	t.Projects = strings.Fields(heading.Properties[projectsProperty])
+	if len(t.Projects) == 0 {
+		t.Projects = nil
+	}
+	t.Clocks = heading.Clocks
+	t.Parent = parent
+	if heading.Closed != nil && (t.CompletedAt == nil || !t.CompletedAt.Truncate(time.Minute).Equal(*heading.Closed)) {
+		t.CompletedAt = heading.Closed
+	}
+	return nil
+}
+
+// Write renders tasks, nested below their parents, and the journal as a
+// datetree into a single Org document
+func Write(w io.Writer, tasks []task.Task, entries []journal.Entry) error {
+	var out strings.Builder
+	out.WriteString("#+TITLE: noted\n")
+	fmt.Fprintf(&out, "#+TODO: %s | %s\n\n", strings.Join(exportKeywords.Todo, " "), strings.Join(exportKeywords.Done, " "))
+
+	ids := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		ids[t.Id] = true
+	}
+	children := make(map[string][]task.Task)
+	for _, t := range tasks {
+		parent := t.Parent
+		if !ids[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], t)
+	}
+
+	out.WriteString("* Tasks\n")
+	var writeTasks func(parent string, level int)
+	writeTasks = func(parent string, level int) {
+		for _, t := range children[parent] {
+			writeTask(&out, t, level)
+			writeTasks(t.Id, level+1)
+		}
+	}
+	writeTasks("", 2)
+
+	out.WriteString("* Journal\n")
+	writeJournal(&out, entries)
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func writeTask(out *strings.Builder, t task.Task, level int) {
+	fmt.Fprintf(out, "%s %s ", strings.Repeat("*", level), t.Status.AsString())
+	if t.Priority != "" {
+		fmt.Fprintf(out, "[#%s] ", t.Priority)
+	}
+	out.WriteString(t.Task)
+	if len(t.Contexts) > 0 {
+		fmt.Fprintf(out, " :%s:", strings.Join(t.Contexts, ":"))
+	}
+	out.WriteRune('\n')
+
+	planning := make([]string, 0, 3)
+	if t.CompletedAt != nil {
+		planning = append(planning, "CLOSED: "+inactive(*t.CompletedAt))
+	}
+	if t.DueAt != nil {
+		planning = append(planning, "DEADLINE: "+active(*t.DueAt))
+	}
+	if t.ScheduledFor != nil {
+		planning = append(planning, "SCHEDULED: "+active(*t.ScheduledFor))
+	}
+	if len(planning) > 0 {
+		out.WriteString(strings.Join(planning, " ") + "\n")
+	}
+
+	out.WriteString(":PROPERTIES:\n")
+	fmt.Fprintf(out, ":%s: %s\n", idProperty, identifier(t))
+	fmt.Fprintf(out, ":%s: %s\n", createdProperty, inactive(t.CreatedAt))
+	if len(t.Projects) > 0 {
+		fmt.Fprintf(out, ":%s: %s\n", projectsProperty, strings.Join(t.Projects, " "))
+	}
+	out.WriteString(":END:\n")
+
+	if len(t.Clocks) > 0 {
+		out.WriteString(":LOGBOOK:\n")
+		for _, clock := range t.Clocks {
+			if clock.End == nil {
+				fmt.Fprintf(out, "CLOCK: %s\n", inactive(clock.Start))
+				continue
+			}
+			minutes := int(clock.Duration().Minutes())
+			fmt.Fprintf(out, "CLOCK: %s--%s => %2d:%02d\n", inactive(clock.Start), inactive(*clock.End), minutes/60, minutes%60)
+		}
+		out.WriteString(":END:\n")
+	}
+
+	if t.Detail == "" {
+		return
+	}
+	for _, line := range strings.Split(t.Detail, "\n") {
+		if strings.HasPrefix(line, "*") {
+			// keep the line from reading as a heading
+			line = " " + line
+		}
+		out.WriteString(line + "\n")
+	}
+}
+
+func writeJournal(out *strings.Builder, entries []journal.Entry) {
+	byDay := make(map[time.Time][]string)
+	days := make([]time.Time, 0)
+	for _, entry := range entries {
+		day := entry.Date()
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], strings.TrimSpace(entry.Message))
+	}
+	sort.Slice(days, func(i int, j int) bool {
+		return days[i].Before(days[j])
+	})
+
+	year, month := 0, time.Month(0)
+	for _, day := range days {
+		if day.Year() != year {
+			year, month = day.Year(), 0
+			fmt.Fprintf(out, "** %d\n", year)
+		}
+		if day.Month() != month {
+			month = day.Month()
+			fmt.Fprintf(out, "*** %s %s\n", day.Format("2006-01"), month)
+		}
+		fmt.Fprintf(out, "**** %s\n", day.Format("2006-01-02 Monday"))
+		for _, message := range byDay[day] {
+			fmt.Fprintf(out, "- %s\n", message)
+		}
+	}
+}
+
+// active renders a date to plan by, leaving out the time of day at midnight
+func active(at time.Time) string {
+	at = at.In(time.Local)
+	if at.Hour() == 0 && at.Minute() == 0 {
+		return at.Format("<2006-01-02 Mon>")
+	}
+	return at.Format("<2006-01-02 Mon 15:04>")
+}
+
+// inactive renders a moment something happened at
+func inactive(at time.Time) string {
+	return at.In(time.Local).Format("[2006-01-02 Mon 15:04]")
+}
+
+func identifier(t task.Task) string {
+	if t.UID != "" {
+		return t.UID
+	}
+	return t.Id
+}
+
+func journalEntry(day time.Time, message string) journal.Entry {
+	return journal.Entry{
+		Year:    day.Year(),
+		Month:   day.Month().String(),
+		Day:     day.Day(),
+		Message: message,
+	}
+}
+
+// items splits the text below a datetree day into list items, paragraphs
+// without a bullet being items of their own
+func items(body []string) []string {
+	result := make([]string, 0)
+	continuing := false
+	for _, line := range body {
+		switch {
+		case line == "":
+			continuing = false
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "+ "):
+			result = append(result, strings.TrimSpace(line[2:]))
+			continuing = true
+		case continuing:
+			result[len(result)-1] += " " + line
+		default:
+			result = append(result, line)
+			continuing = true
+		}
+	}
+	return result
+}
+
+func nonEmpty(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,265 @@
+// Package org reads and writes Org-mode files, see https://orgmode.org
+package org
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"noted/task"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(\*+)\s+(.*)$`)
+	priorityPattern  = regexp.MustCompile(`^\[#([A-Z])\]\s*`)
+	tagsPattern      = regexp.MustCompile(`\s+(:[\w@#%:]+:)\s*$`)
+	planningPattern  = regexp.MustCompile(`(DEADLINE|SCHEDULED|CLOSED):\s*([<\[][^>\]]+[>\]])`)
+	propertyPattern  = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+	clockPattern     = regexp.MustCompile(`^CLOCK:\s*(\[[^\]]+\])(?:--(\[[^\]]+\]))?`)
+	timestampPattern = regexp.MustCompile(`^[<\[](\d{4}-\d{2}-\d{2})(?:\s+[^\s\d>\]]+)?(?:\s+(\d{1,2}:\d{2}))?`)
+	keywordsPattern  = regexp.MustCompile(`^#\+(?:SEQ_|TYP_)?TODO:\s*(.*)$`)
+)
+
+// Keywords are the TODO keywords of a document, split into the states that
+// still need work and the ones that are finished
+type Keywords struct {
+	Todo []string
+	Done []string
+}
+
+// DefaultKeywords are Org's own defaults, used when a file declares none
+var DefaultKeywords = Keywords{Todo: []string{"TODO"}, Done: []string{"DONE"}}
+
+func (k Keywords) contains(word string) bool {
+	for _, keyword := range append(k.Todo, k.Done...) {
+		if keyword == word {
+			return true
+		}
+	}
+	return false
+}
+
+func (k Keywords) isDone(word string) bool {
+	for _, keyword := range k.Done {
+		if keyword == word {
+			return true
+		}
+	}
+	return false
+}
+
+type Heading struct {
+	Level      int
+	Keyword    string
+	Priority   string
+	Title      string
+	Tags       []string
+	Deadline   *time.Time
+	Scheduled  *time.Time
+	Closed     *time.Time
+	Properties map[string]string
+	Clocks     []task.Clock
+	// Body holds the text of the section below the heading, without its
+	// planning line, drawers and clocks
+	Body     []string
+	Children []*Heading
+}
+
+type Document struct {
+	Keywords Keywords
+	Headings []*Heading
+}
+
+// Parse reads an Org document. Text before the first heading is ignored
+// except for #+TODO lines declaring keywords.
+func Parse(r io.Reader) (*Document, error) {
+	doc := &Document{}
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if match := keywordsPattern.FindStringSubmatch(line); match != nil {
+			doc.Keywords = doc.Keywords.add(match[1])
+		}
+	}
+	if len(doc.Keywords.Todo)+len(doc.Keywords.Done) == 0 {
+		doc.Keywords = DefaultKeywords
+	}
+
+	// the innermost open heading of each level
+	var stack []*Heading
+	var current *Heading
+	inDrawer := false
+
+	for number, line := range lines {
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			current = doc.heading(len(match[1]), match[2])
+			inDrawer = false
+			for len(stack) > 0 && stack[len(stack)-1].Level >= current.Level {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				doc.Headings = append(doc.Headings, current)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, current)
+			}
+			stack = append(stack, current)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == ":END:":
+			inDrawer = false
+		case trimmed == ":PROPERTIES:" || trimmed == ":LOGBOOK:":
+			inDrawer = true
+		case strings.HasPrefix(trimmed, "CLOCK:"):
+			clock, err := parseClock(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number+1, err)
+			}
+			current.Clocks = append(current.Clocks, clock)
+		case inDrawer:
+			if match := propertyPattern.FindStringSubmatch(trimmed); match != nil {
+				current.Properties[strings.ToUpper(match[1])] = strings.TrimSpace(match[2])
+			}
+		case len(current.Body) == 0 && planningPattern.MatchString(trimmed) && strings.Trim(planningPattern.ReplaceAllString(trimmed, ""), " ") == "":
+			for _, match := range planningPattern.FindAllStringSubmatch(trimmed, -1) {
+				at, err := parseTimestamp(match[2])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", number+1, err)
+				}
+				switch match[1] {
+				case "DEADLINE":
+					current.Deadline = at
+				case "SCHEDULED":
+					current.Scheduled = at
+				case "CLOSED":
+					current.Closed = at
+				}
+			}
+		default:
+			if trimmed != "" || len(current.Body) > 0 {
+				current.Body = append(current.Body, trimmed)
+			}
+		}
+	}
+
+	doc.walk(func(heading *Heading) {
+		// drop the blank lines separating a section from the next heading
+		for len(heading.Body) > 0 && heading.Body[len(heading.Body)-1] == "" {
+			heading.Body = heading.Body[:len(heading.Body)-1]
+		}
+	})
+	return doc, nil
+}
+
+func (k Keywords) add(declaration string) Keywords {
+	done := false
+	for _, word := range strings.Fields(declaration) {
+		if word == "|" {
+			done = true
+			continue
+		}
+		// strip fast access keys and logging settings such as DONE(d!)
+		if i := strings.IndexRune(word, '('); i > 0 {
+			word = word[:i]
+		}
+		if done {
+			k.Done = append(k.Done, word)
+		} else {
+			k.Todo = append(k.Todo, word)
+		}
+	}
+	if !done && len(k.Todo) > 0 {
+		// without a bar the last keyword is the finished state
+		k.Done = append(k.Done, k.Todo[len(k.Todo)-1])
+		k.Todo = k.Todo[:len(k.Todo)-1]
+	}
+	return k
+}
+
+func (d *Document) heading(level int, text string) *Heading {
+	heading := &Heading{Level: level, Properties: make(map[string]string)}
+
+	if match := tagsPattern.FindStringSubmatch(text); match != nil {
+		text = strings.TrimSuffix(text, match[0])
+		for _, tag := range strings.Split(strings.Trim(match[1], ":"), ":") {
+			if tag != "" {
+				heading.Tags = append(heading.Tags, tag)
+			}
+		}
+	}
+
+	if keyword, rest, _ := strings.Cut(text, " "); d.Keywords.contains(keyword) {
+		heading.Keyword = keyword
+		text = rest
+	}
+
+	if match := priorityPattern.FindStringSubmatch(text); match != nil {
+		heading.Priority = match[1]
+		text = strings.TrimPrefix(text, match[0])
+	}
+
+	heading.Title = strings.TrimSpace(text)
+	return heading
+}
+
+func (d *Document) walk(visit func(*Heading)) {
+	var walk func([]*Heading)
+	walk = func(headings []*Heading) {
+		for _, heading := range headings {
+			visit(heading)
+			walk(heading.Children)
+		}
+	}
+	walk(d.Headings)
+}
+
+func parseTimestamp(value string) (*time.Time, error) {
+	match := timestampPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("invalid timestamp %s", value)
+	}
+
+	layout, text := "2006-01-02", match[1]
+	if match[2] != "" {
+		layout, text = "2006-01-02 15:04", match[1]+" "+match[2]
+	}
+	at, err := time.ParseInLocation(layout, text, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %s: %w", value, err)
+	}
+	return &at, nil
+}
+
+func parseClock(line string) (task.Clock, error) {
+	match := clockPattern.FindStringSubmatch(line)
+	if match == nil {
+		return task.Clock{}, fmt.Errorf("invalid clock %s", line)
+	}
+
+	start, err := parseTimestamp(match[1])
+	if err != nil {
+		return task.Clock{}, err
+	}
+	clock := task.Clock{Start: *start}
+	if match[2] != "" {
+		if clock.End, err = parseTimestamp(match[2]); err != nil {
+			return task.Clock{}, err
+		}
+	}
+	return clock, nil
+}
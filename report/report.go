@@ -0,0 +1,171 @@
+// Package report renders journal entries and tasks into shareable documents
+package report
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	htmltemplate "html/template"
+	"io"
+	"noted/config"
+	"noted/journal"
+	"noted/task"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var defaults embed.FS
+
+type Format string
+
+const (
+	Markdown Format = "md"
+	HTML     Format = "html"
+)
+
+// Day holds the journal entries of a single day
+type Day struct {
+	Date    time.Time
+	Entries []string
+}
+
+// StatusGroup holds the tasks sharing a status
+type StatusGroup struct {
+	Status task.Status
+	Tasks  []task.Task
+}
+
+// Report is the data templates are executed with. From and To are nil when
+// the report is not bounded on that side.
+type Report struct {
+	From      *time.Time
+	To        *time.Time
+	Generated time.Time
+	Days      []Day
+	Tasks     []StatusGroup
+}
+
+// statuses are listed in the order work flows through them
+var statusOrder = []task.Status{task.InProgress, task.ToDo, task.Scheduled, task.Paused, task.Done, task.Cancelled}
+
+// New collects the journal entries written between from and to, and the
+// tasks with any activity in that period: created, due, scheduled, completed
+// or modified. The period includes the whole day to falls on.
+func New(from *time.Time, to *time.Time, entries []journal.Entry, tasks []task.Task) Report {
+	report := Report{From: from, To: to, Generated: time.Now()}
+	if to != nil {
+		end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, to.Location())
+		to = &end
+	}
+
+	byDay := make(map[time.Time]*Day)
+	for _, entry := range entries {
+		date := entry.Date()
+		if !within(date, from, to) {
+			continue
+		}
+		if byDay[date] == nil {
+			byDay[date] = &Day{Date: date}
+		}
+		byDay[date].Entries = append(byDay[date].Entries, strings.TrimSpace(entry.Message))
+	}
+	for _, day := range byDay {
+		report.Days = append(report.Days, *day)
+	}
+	sort.Slice(report.Days, func(i int, j int) bool {
+		return report.Days[i].Date.Before(report.Days[j].Date)
+	})
+
+	byStatus := make(map[task.Status][]task.Task)
+	for _, t := range tasks {
+		if active(t, from, to) {
+			byStatus[t.Status] = append(byStatus[t.Status], t)
+		}
+	}
+	for _, status := range statusOrder {
+		if len(byStatus[status]) > 0 {
+			report.Tasks = append(report.Tasks, StatusGroup{Status: status, Tasks: byStatus[status]})
+		}
+	}
+
+	return report
+}
+
+// Render executes the template for format, preferring a template of the same
+// name in the storage directory's template folder over the built in one
+func Render(w io.Writer, format Format, report Report) error {
+	name := fmt.Sprintf("report.%s.tmpl", format)
+
+	source, err := os.ReadFile(path.Join(TemplateDirectory(), name))
+	if errors.Is(err, os.ErrNotExist) {
+		source, err = defaults.ReadFile(path.Join("templates", name))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case HTML:
+		tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(functions)).Parse(string(source))
+		if err != nil {
+			return fmt.Errorf("invalid template %s: %w", name, err)
+		}
+		return tmpl.Execute(w, report)
+	case Markdown:
+		tmpl, err := texttemplate.New(name).Funcs(functions).Parse(string(source))
+		if err != nil {
+			return fmt.Errorf("invalid template %s: %w", name, err)
+		}
+		return tmpl.Execute(w, report)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// TemplateDirectory is where users keep templates overriding the built in ones
+func TemplateDirectory() string {
+	return path.Join(viper.GetString(noted.ConfigStorageDir), viper.GetString(noted.ConfigTemplatePrefix))
+}
+
+var functions = texttemplate.FuncMap{
+	"date": func(at time.Time) string {
+		return at.Format("Monday, January 2 2006")
+	},
+	"day": func(at *time.Time) string {
+		if at == nil {
+			return ""
+		}
+		return at.Format("2006-01-02")
+	},
+	"status": func(status task.Status) string {
+		return status.AsString()
+	},
+}
+
+func within(at time.Time, from *time.Time, to *time.Time) bool {
+	return (from == nil || !at.Before(*from)) && (to == nil || at.Before(*to))
+}
+
+func active(t task.Task, from *time.Time, to *time.Time) bool {
+	if from == nil && to == nil {
+		return true
+	}
+
+	moments := []*time.Time{&t.CreatedAt, t.DueAt, t.ScheduledFor, t.CompletedAt}
+	for _, at := range t.Modified {
+		at := at
+		moments = append(moments, &at)
+	}
+	for _, at := range moments {
+		if at != nil && within(*at, from, to) {
+			return true
+		}
+	}
+	return false
+}
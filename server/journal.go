@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"noted/journal"
+	"noted/task"
+	"slices"
+	"strings"
+	"time"
+)
+
+type journalResponse struct {
+	Date    string `json:"date"`
+	Message string `json:"message"`
+}
+
+type journalRequest struct {
+	Date    string `json:"date"`
+	Message string `json:"message"`
+}
+
+// handleJournal serves /journal
+//
+//	GET    /journal?from=2023-10-01&to=2023-10-31  list entries, oldest first
+//	POST   /journal                                 append an entry, for today unless a date is given
+//	DELETE /journal                                 move the entry with the date and message given to the trash
+func handleJournal(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listJournal(w, r)
+	case http.MethodPost:
+		appendJournal(w, r)
+	case http.MethodDelete:
+		deleteJournal(w, r)
+	default:
+		methodNotAllowed(w, "GET, POST, DELETE")
+	}
+}
+
+func listJournal(w http.ResponseWriter, r *http.Request) {
+	from, err := task.ParseDate(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	to, err := task.ParseDate(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	all, err := journal.GetEntries(false)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	// files are read in name order, which is not the order of the months
+	slices.SortStableFunc(all, func(a journal.Entry, b journal.Entry) int {
+		return a.Date().Compare(b.Date())
+	})
+
+	entries := make([]journalResponse, 0)
+	for _, entry := range all {
+		date := entry.Date()
+		if (from != nil && date.Before(*from)) || (to != nil && date.After(*to)) {
+			continue
+		}
+		entries = append(entries, journalResponse{
+			Date:    date.Format("2006-01-02"),
+			Message: strings.TrimSpace(entry.Message),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func appendJournal(w http.ResponseWriter, r *http.Request) {
+	var request journalRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Message == "" {
+		writeError(w, http.StatusBadRequest, errors.New("message is required"))
+		return
+	}
+
+	date := time.Now()
+	if request.Date != "" {
+		parsed, err := task.ParseDate(request.Date)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		date = *parsed
+	}
+
+	if err := journal.SaveJournalEntry(date, request.Message); err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, journalResponse{
+		Date:    date.Format("2006-01-02"),
+		Message: request.Message,
+	})
+}
+
+func deleteJournal(w http.ResponseWriter, r *http.Request) {
+	var request journalRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Date == "" || request.Message == "" {
+		writeError(w, http.StatusBadRequest, errors.New("date and message are required"))
+		return
+	}
+	date, err := task.ParseDate(request.Date)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entry := journal.Entry{
+		Year:    date.Year(),
+		Month:   date.Month().String(),
+		Day:     date.Day(),
+		Message: request.Message,
+	}
+	if err = journal.DeleteEntry(entry); err != nil {
+		writeFailure(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"noted/calendar"
+	"noted/journal"
+	"noted/logging"
+	"noted/task"
+	"sync"
+	"time"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+const CalDAVPrefix = "/caldav"
+
+// NewHandler serves the task and journal REST API, and the tasks over CalDAV
+// under CalDAVPrefix when caldav is set
+func NewHandler(caldav bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tasks", handleTasks)
+	mux.HandleFunc("/tasks/", handleTask)
+	mux.HandleFunc("/journal", handleJournal)
+	if caldav {
+		calendarHandler := calendar.NewCalDAVHandler(CalDAVPrefix)
+		mux.Handle(CalDAVPrefix, calendarHandler)
+		mux.Handle(CalDAVPrefix+"/", calendarHandler)
+		mux.Handle("/.well-known/caldav", calendarHandler)
+	}
+	return logRequests(serialize(mux))
+}
+
+// storeLock keeps requests changing the store, over the API or CalDAV, from
+// interleaving their reads and writes of the same files
+var storeLock sync.RWMutex
+
+// readOnly are the methods that do not change the store
+var readOnly = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	"PROPFIND":         true,
+	"REPORT":           true,
+}
+
+func serialize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readOnly[r.Method] {
+			storeLock.RLock()
+			defer storeLock.RUnlock()
+		} else {
+			storeLock.Lock()
+			defer storeLock.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		logging.Logger.Info("request",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", recorder.status),
+			zap.Duration("duration", time.Since(started)),
+		)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logging.Logger.Error("failed to encode response", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeFailure maps errors from the task and journal packages to a status code
+func writeFailure(w http.ResponseWriter, err error) {
+	var notFound task.NotFoundError
+	var ambiguous task.AmbiguousIdError
+	switch {
+	case errors.As(err, &notFound), errors.Is(err, journal.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &ambiguous):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func decode(w http.ResponseWriter, r *http.Request, into any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(into); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	config "noted/config"
+	"noted/task"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newServer serves an empty store in a temporary directory
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	store := t.TempDir()
+	for _, directory := range []string{"task", "journal"} {
+		if err := os.MkdirAll(path.Join(store, directory), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.Set(config.ConfigStorageDir, store)
+	viper.Set(config.ConfigTaskPrefix, "task")
+	viper.Set(config.ConfigJournalPrefix, "journal")
+
+	server := httptest.NewServer(NewHandler(false))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// request sends body, when there is one, as JSON and decodes the JSON
+// response into into, when given, failing unless the status is the expected one
+func request(t *testing.T, server *httptest.Server, method string, target string, body string, status int, into any) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var contents bytes.Buffer
+	contents.ReadFrom(response.Body)
+	if response.StatusCode != status {
+		t.Fatalf("%s %s returned %d, want %d: %s", method, target, response.StatusCode, status, contents.String())
+	}
+	if into != nil {
+		if err = json.Unmarshal(contents.Bytes(), into); err != nil {
+			t.Fatalf("%s %s: %s: %s", method, target, err, contents.String())
+		}
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	server := newServer(t)
+
+	var created taskResponse
+	request(t, server, http.MethodPost, "/tasks", `{"task": "buy milk", "due_at": "2023-09-10"}`, http.StatusCreated, &created)
+	if created.Id == "" || created.Task != "buy milk" || created.Status != "TODO" || created.DueAt == nil {
+		t.Fatalf("created %+v", created)
+	}
+
+	var found taskResponse
+	request(t, server, http.MethodGet, "/tasks/"+created.Id[:8], "", http.StatusOK, &found)
+	if found.Id != created.Id {
+		t.Errorf("found %s by its prefix, want %s", found.Id, created.Id)
+	}
+
+	var updated taskResponse
+	request(t, server, http.MethodPut, "/tasks/"+created.Id, `{"detail": "semi-skimmed"}`, http.StatusOK, &updated)
+	if updated.Detail != "semi-skimmed" || updated.Task != "buy milk" {
+		t.Errorf("updated %+v, want the detail changed and the task kept", updated)
+	}
+
+	request(t, server, http.MethodPost, "/tasks/"+created.Id+"/status", `{"status": "done"}`, http.StatusOK, &updated)
+	if updated.Status != "DONE" {
+		t.Errorf("status is %s after changing it to done", updated.Status)
+	}
+
+	var listed []taskResponse
+	request(t, server, http.MethodGet, "/tasks?status=DONE&q=milk", "", http.StatusOK, &listed)
+	if len(listed) != 1 || listed[0].Id != created.Id {
+		t.Errorf("listed %+v, want the done task", listed)
+	}
+	request(t, server, http.MethodGet, "/tasks?status=TODO", "", http.StatusOK, &listed)
+	if len(listed) != 0 {
+		t.Errorf("listed %+v, want no task to do", listed)
+	}
+
+	request(t, server, http.MethodDelete, "/tasks/"+created.Id, "", http.StatusNoContent, nil)
+	request(t, server, http.MethodGet, "/tasks/"+created.Id, "", http.StatusNotFound, nil)
+}
+
+func TestTaskErrors(t *testing.T) {
+	server := newServer(t)
+	for _, id := range []string{"abcd0000-0000-4000-8000-000000000001", "abcd0000-0000-4000-8000-000000000002"} {
+		if _, err := task.AddTask(task.Task{Id: id, Task: "twin"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"missing task", http.MethodPost, "/tasks", `{"detail": "no title"}`, http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/tasks", `{"task": "x", "priority": 1}`, http.StatusBadRequest},
+		{"malformed body", http.MethodPost, "/tasks", `{"task":`, http.StatusBadRequest},
+		{"bad date", http.MethodPost, "/tasks", `{"task": "x", "due_at": "tomorrow"}`, http.StatusBadRequest},
+		{"bad status filter", http.MethodGet, "/tasks?status=someday", "", http.StatusBadRequest},
+		{"bad status", http.MethodPost, "/tasks/abcd0000-0000-4000-8000-000000000001/status", `{"status": "someday"}`, http.StatusBadRequest},
+		{"unknown task", http.MethodGet, "/tasks/ffffffff", "", http.StatusNotFound},
+		{"unknown action", http.MethodGet, "/tasks/abcd0000-0000-4000-8000-000000000001/notes", "", http.StatusNotFound},
+		{"ambiguous id", http.MethodGet, "/tasks/abcd", "", http.StatusConflict},
+		{"tasks method", http.MethodDelete, "/tasks", "", http.StatusMethodNotAllowed},
+		{"task method", http.MethodPost, "/tasks/abcd0000-0000-4000-8000-000000000001", "", http.StatusMethodNotAllowed},
+		{"status method", http.MethodGet, "/tasks/abcd0000-0000-4000-8000-000000000001/status", "", http.StatusMethodNotAllowed},
+		{"journal method", http.MethodPut, "/journal", "", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var failure errorResponse
+			request(t, server, test.method, test.target, test.body, test.status, &failure)
+			if failure.Error == "" {
+				t.Error("no error message in the response")
+			}
+		})
+	}
+}
+
+func TestJournal(t *testing.T) {
+	server := newServer(t)
+
+	// months whose files sort in another order than the dates
+	for _, entry := range []string{
+		`{"date": "2023-10-02", "message": "october"}`,
+		`{"date": "2023-08-20", "message": "august"}`,
+		`{"date": "2023-09-05", "message": "september"}`,
+	} {
+		request(t, server, http.MethodPost, "/journal", entry, http.StatusCreated, nil)
+	}
+
+	var entries []journalResponse
+	request(t, server, http.MethodGet, "/journal", "", http.StatusOK, &entries)
+	var messages []string
+	for _, entry := range entries {
+		messages = append(messages, entry.Message)
+	}
+	if strings.Join(messages, " ") != "august september october" {
+		t.Errorf("listed %v, want oldest first", messages)
+	}
+
+	request(t, server, http.MethodGet, "/journal?from=2023-09-01&to=2023-09-30", "", http.StatusOK, &entries)
+	if len(entries) != 1 || entries[0].Date != "2023-09-05" {
+		t.Errorf("listed %+v, want the september entry", entries)
+	}
+	request(t, server, http.MethodGet, "/journal?from=september", "", http.StatusBadRequest, nil)
+
+	var added journalResponse
+	request(t, server, http.MethodPost, "/journal", `{"message": "today"}`, http.StatusCreated, &added)
+	if added.Date != time.Now().Format("2006-01-02") {
+		t.Errorf("entry without a date added on %s, want today", added.Date)
+	}
+	request(t, server, http.MethodPost, "/journal", `{"date": "2023-09-05"}`, http.StatusBadRequest, nil)
+
+	request(t, server, http.MethodDelete, "/journal", `{"date": "2023-09-05", "message": "september"}`, http.StatusNoContent, nil)
+	request(t, server, http.MethodDelete, "/journal", `{"date": "2023-09-05", "message": "september"}`, http.StatusNotFound, nil)
+	request(t, server, http.MethodDelete, "/journal", `{"message": "september"}`, http.StatusBadRequest, nil)
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	server := newServer(t)
+
+	const count = 20
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			request(t, server, http.MethodPost, "/tasks", fmt.Sprintf(`{"task": "task %d"}`, i), http.StatusCreated, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	var listed []taskResponse
+	request(t, server, http.MethodGet, "/tasks", "", http.StatusOK, &listed)
+	if len(listed) != count {
+		t.Errorf("listed %d tasks after creating %d at once", len(listed), count)
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"noted/task"
+	"strings"
+	"time"
+)
+
+type taskResponse struct {
+	Id           string     `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	Task         string     `json:"task"`
+	Detail       string     `json:"detail"`
+	Status       string     `json:"status"`
+}
+
+// taskRequest is used for both creating and updating, where absent fields
+// are left untouched
+type taskRequest struct {
+	Task         *string `json:"task"`
+	Detail       *string `json:"detail"`
+	DueAt        *string `json:"due_at"`
+	ScheduledFor *string `json:"scheduled_for"`
+	Status       *string `json:"status"`
+}
+
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+func newTaskResponse(t task.Task) taskResponse {
+	return taskResponse{
+		Id:           t.Id,
+		CreatedAt:    t.CreatedAt,
+		DueAt:        t.DueAt,
+		ScheduledFor: t.ScheduledFor,
+		Task:         t.Task,
+		Detail:       t.Detail,
+		Status:       t.Status.AsString(),
+	}
+}
+
+// apply copies the fields present in the request onto t
+func (r taskRequest) apply(t *task.Task) error {
+	var err error
+	if r.Task != nil {
+		t.Task = *r.Task
+	}
+	if r.Detail != nil {
+		t.Detail = *r.Detail
+	}
+	if r.DueAt != nil {
+		if t.DueAt, err = task.ParseDate(*r.DueAt); err != nil {
+			return err
+		}
+	}
+	if r.ScheduledFor != nil {
+		if t.ScheduledFor, err = task.ParseDate(*r.ScheduledFor); err != nil {
+			return err
+		}
+	}
+	if r.Status != nil {
+		if t.Status, err = task.ParseStatus(*r.Status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleTasks serves /tasks
+//
+//	GET  /tasks?status=TODO&q=text  list tasks, optionally filtered
+//	POST /tasks                     create a task
+func handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listTasks(w, r)
+	case http.MethodPost:
+		createTask(w, r)
+	default:
+		methodNotAllowed(w, "GET, POST")
+	}
+}
+
+func listTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	statuses := make(map[task.Status]bool)
+	for _, value := range query["status"] {
+		for _, name := range strings.Split(value, ",") {
+			status, err := task.ParseStatus(name)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			statuses[status] = true
+		}
+	}
+	search := strings.ToLower(query.Get("q"))
+
+	all, err := task.ListTasks(true)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	tasks := make([]taskResponse, 0)
+	for _, t := range all {
+		if len(statuses) > 0 && !statuses[t.Status] {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(t.Task+" "+t.Detail), search) {
+			continue
+		}
+		tasks = append(tasks, newTaskResponse(t))
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func createTask(w http.ResponseWriter, r *http.Request) {
+	var request taskRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Task == nil || *request.Task == "" {
+		writeError(w, http.StatusBadRequest, errors.New("task is required"))
+		return
+	}
+
+	var draft task.Task
+	if err := request.apply(&draft); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	created, err := task.CreateTask(draft.Task, draft.Detail, draft.DueAt)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	// creation only takes the basics, anything else is an update
+	if draft.ScheduledFor != nil || draft.Status != task.ToDo {
+		created.ScheduledFor = draft.ScheduledFor
+		created.Status = draft.Status
+		if err = task.UpdateTask(created); err != nil {
+			writeFailure(w, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, newTaskResponse(created))
+}
+
+// handleTask serves a single task
+//
+//	GET    /tasks/{id}
+//	PUT    /tasks/{id}         update the fields present in the body
+//	DELETE /tasks/{id}         move the task to the trash
+//	POST   /tasks/{id}/status  {"status": "DONE"}
+func handleTask(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+	if id == "" || (action != "" && action != "status") {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	t, err := task.FindTask(id)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	if action == "status" {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, "POST")
+			return
+		}
+		changeStatus(w, r, t)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, newTaskResponse(t))
+	case http.MethodPut, http.MethodPatch:
+		var request taskRequest
+		if !decode(w, r, &request) {
+			return
+		}
+		if err = request.apply(&t); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err = task.UpdateTask(t); err != nil {
+			writeFailure(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newTaskResponse(t))
+	case http.MethodDelete:
+		if err = task.DeleteTask(t); err != nil {
+			writeFailure(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, "GET, PUT, PATCH, DELETE")
+	}
+}
+
+func changeStatus(w http.ResponseWriter, r *http.Request, t task.Task) {
+	var request statusRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	status, err := task.ParseStatus(request.Status)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t.Status = status
+	if err = task.UpdateTask(t); err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newTaskResponse(t))
+}
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
+)
+
+var (
+	headingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	labelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Width(12)
+	createdStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	doneStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	barStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// barWidth is the length of the longest bar in a bar chart
+const barWidth = 40
+
+// Render draws the statistics as terminal charts
+func Render(stats Stats) string {
+	var out strings.Builder
+
+	created := make([]int, len(stats.Tasks.Weeks))
+	completed := make([]int, len(stats.Tasks.Weeks))
+	for i, week := range stats.Tasks.Weeks {
+		created[i], completed[i] = week.Created, week.Completed
+	}
+
+	out.WriteString(headingStyle.Render(fmt.Sprintf("Tasks, last %d weeks", len(stats.Tasks.Weeks))) + "\n")
+	out.WriteString(labelStyle.Render("created") + createdStyle.Render(sparkline(created)) + fmt.Sprintf("  %d in total\n", stats.Tasks.Created))
+	out.WriteString(labelStyle.Render("completed") + doneStyle.Render(sparkline(completed)) + fmt.Sprintf("  %d in total\n", stats.Tasks.Completed))
+	out.WriteString(labelStyle.Render("open") + fmt.Sprintf("%d", stats.Tasks.Open))
+	if stats.Tasks.Overdue > 0 {
+		out.WriteString(warnStyle.Render(fmt.Sprintf(", %d overdue", stats.Tasks.Overdue)))
+	}
+	out.WriteString("\n")
+	if stats.Tasks.Completed > 0 {
+		out.WriteString(labelStyle.Render("completion") + fmt.Sprintf("%s on average\n", humanize(stats.Tasks.AverageCompletion)))
+	}
+
+	out.WriteString("\n" + headingStyle.Render(fmt.Sprintf("Journal, words over the last %d days", len(stats.Journal.Days))) + "\n")
+	most := 0
+	for _, day := range stats.Journal.Days {
+		most = max(most, day.Words)
+	}
+	for _, day := range stats.Journal.Days {
+		out.WriteString(labelStyle.Render(day.Date.Format("Mon Jan 2")) + barStyle.Render(bar(day.Words, most)) + fmt.Sprintf(" %d\n", day.Words))
+	}
+	out.WriteString(labelStyle.Render("streak") + fmt.Sprintf("%s, longest %s\n", days(stats.Journal.CurrentStreak), days(stats.Journal.LongestStreak)))
+	out.WriteString(labelStyle.Render("words/day") + fmt.Sprintf("%.1f over %s written\n", stats.Journal.WordsPerDay, days(stats.Journal.DaysWritten)))
+
+	return out.String()
+}
+
+func sparkline(values []int) string {
+	most := 0
+	for _, value := range values {
+		most = max(most, value)
+	}
+
+	line := make([]rune, len(values))
+	for i, value := range values {
+		if most == 0 {
+			line[i] = sparks[0]
+			continue
+		}
+		line[i] = sparks[value*(len(sparks)-1)/most]
+	}
+	return string(line)
+}
+
+func bar(value int, most int) string {
+	if most == 0 || value == 0 {
+		return ""
+	}
+	// anything written gets at least a sliver
+	return strings.Repeat("█", max(1, value*barWidth/most))
+}
+
+func humanize(duration time.Duration) string {
+	switch {
+	case duration >= 48*time.Hour:
+		return fmt.Sprintf("%.1f days", duration.Hours()/24)
+	case duration >= time.Hour:
+		return fmt.Sprintf("%.1f hours", duration.Hours())
+	default:
+		return fmt.Sprintf("%.0f minutes", duration.Minutes())
+	}
+}
+
+func days(count int) string {
+	if count == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", count)
+}
@@ -0,0 +1,180 @@
+// Package stats summarizes how tasks and the journal evolve over time
+package stats
+
+import (
+	"math"
+	"noted/journal"
+	"noted/task"
+	"strings"
+	"time"
+)
+
+type Week struct {
+	Start     time.Time `json:"start"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+}
+
+type Day struct {
+	Date  time.Time `json:"date"`
+	Words int       `json:"words"`
+}
+
+type TaskStats struct {
+	Weeks     []Week `json:"weeks"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	Open      int    `json:"open"`
+	Overdue   int    `json:"overdue"`
+	// AverageCompletion is the mean time from creating a task to finishing it
+	AverageCompletion time.Duration `json:"-"`
+	AverageDays       float64       `json:"average_completion_days"`
+}
+
+type JournalStats struct {
+	Days          []Day   `json:"days"`
+	Entries       int     `json:"entries"`
+	DaysWritten   int     `json:"days_written"`
+	CurrentStreak int     `json:"current_streak"`
+	LongestStreak int     `json:"longest_streak"`
+	WordsPerDay   float64 `json:"words_per_day"`
+}
+
+type Stats struct {
+	Tasks   TaskStats    `json:"tasks"`
+	Journal JournalStats `json:"journal"`
+}
+
+// Compute gathers statistics as of now. Weekly task counts cover the given
+// number of weeks, starting on Mondays, and daily word counts the given
+// number of days; streaks and averages use the whole history.
+func Compute(tasks []task.Task, entries []journal.Entry, now time.Time, weeks int, days int) Stats {
+	today := startOfDay(now)
+	return Stats{
+		Tasks:   taskStats(tasks, today, now, weeks),
+		Journal: journalStats(entries, today, days),
+	}
+}
+
+func taskStats(tasks []task.Task, today time.Time, now time.Time, weeks int) TaskStats {
+	stats := TaskStats{Weeks: make([]Week, weeks)}
+
+	// weeks start on Monday
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	first := monday.AddDate(0, 0, -7*(weeks-1))
+	for i := range stats.Weeks {
+		stats.Weeks[i].Start = first.AddDate(0, 0, 7*i)
+	}
+	week := func(at time.Time) int {
+		if at.Before(first) {
+			return -1
+		}
+		// rounded as days around daylight saving changes are not 24 hours long
+		return int(math.Round(startOfDay(at).Sub(first).Hours()/24)) / 7
+	}
+
+	var total time.Duration
+	for _, t := range tasks {
+		stats.Created++
+		if i := week(t.CreatedAt); i >= 0 && i < weeks {
+			stats.Weeks[i].Created++
+		}
+
+		if t.Open() {
+			stats.Open++
+			if t.Overdue(now) {
+				stats.Overdue++
+			}
+			continue
+		}
+		if t.Status != task.Done {
+			continue
+		}
+
+		stats.Completed++
+		if completed := completedAt(t); completed != nil {
+			if i := week(*completed); i >= 0 && i < weeks {
+				stats.Weeks[i].Completed++
+			}
+			total += completed.Sub(t.CreatedAt)
+		}
+	}
+
+	if stats.Completed > 0 {
+		stats.AverageCompletion = total / time.Duration(stats.Completed)
+		stats.AverageDays = stats.AverageCompletion.Hours() / 24
+	}
+	return stats
+}
+
+// completedAt falls back to when the status last changed for tasks finished
+// before completion times were recorded
+func completedAt(t task.Task) *time.Time {
+	if t.CompletedAt != nil {
+		return t.CompletedAt
+	}
+	if changed, ok := t.Modified["status"]; ok {
+		return &changed
+	}
+	return nil
+}
+
+func journalStats(entries []journal.Entry, today time.Time, days int) JournalStats {
+	stats := JournalStats{Days: make([]Day, days), Entries: len(entries)}
+	first := today.AddDate(0, 0, -(days - 1))
+	for i := range stats.Days {
+		stats.Days[i].Date = first.AddDate(0, 0, i)
+	}
+
+	words := make(map[time.Time]int)
+	for _, entry := range entries {
+		words[entry.Date()] += len(strings.Fields(entry.Message))
+	}
+	for i, day := range stats.Days {
+		stats.Days[i].Words = words[day.Date]
+	}
+
+	total := 0
+	for _, count := range words {
+		total += count
+	}
+	stats.DaysWritten = len(words)
+	if stats.DaysWritten > 0 {
+		stats.WordsPerDay = float64(total) / float64(stats.DaysWritten)
+	}
+
+	// a streak still counts when today has no entry yet
+	start := today
+	if _, ok := words[today]; !ok {
+		start = today.AddDate(0, 0, -1)
+	}
+	for day := start; hasDay(words, day); day = day.AddDate(0, 0, -1) {
+		stats.CurrentStreak++
+	}
+
+	for day := range words {
+		if hasDay(words, day.AddDate(0, 0, -1)) {
+			// not the first day of a streak
+			continue
+		}
+		length := 0
+		for next := day; hasDay(words, next); next = next.AddDate(0, 0, 1) {
+			length++
+		}
+		if length > stats.LongestStreak {
+			stats.LongestStreak = length
+		}
+	}
+
+	return stats
+}
+
+func hasDay(words map[time.Time]int, day time.Time) bool {
+	_, ok := words[day]
+	return ok
+}
+
+func startOfDay(at time.Time) time.Time {
+	at = at.In(time.Local)
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.Local)
+}
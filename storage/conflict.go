@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+)
+
+// file sync tools keep both versions when a file changed on two machines,
+// writing the second one next to the original under a decorated name:
+//
+//	Syncthing: 2023-September.sync-conflict-20231001-123456-ABCDEFG.yaml
+//	Dropbox:   2023-September (conflicted copy 2023-10-01).yaml
+//	           2023-September (Jane's conflicted copy 2023-10-01).yaml
+var conflictCopyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\.sync-conflict-\d{8}-\d{6}-[A-Z0-9]+`),
+	regexp.MustCompile(` \([^()]*conflicted copy[^()]*\)`),
+}
+
+func IsConflictCopy(name string) bool {
+	return ConflictOriginal(name) != name
+}
+
+// ConflictOriginal returns the name of the file a conflict copy was made of,
+// or name itself when it is not a conflict copy
+func ConflictOriginal(name string) string {
+	for _, pattern := range conflictCopyPatterns {
+		if pattern.MatchString(name) {
+			return pattern.ReplaceAllString(name, "")
+		}
+	}
+	return name
+}
+
+// ConflictCopies lists the paths of the conflict copies in dir
+func ConflictCopies(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir() && IsConflictCopy(file.Name()) {
+			copies = append(copies, path.Join(dir, file.Name()))
+		}
+	}
+	return copies, nil
+}
+
+// Merger merges two versions of a file that derive from base, as the task and
+// journal packages do for their formats. An empty base stands for an unknown
+// ancestor.
+type Merger func(base []byte, ours []byte, theirs []byte) ([]byte, error)
+
+// BaseFinder recovers the version of file that both the original and the
+// conflict copy, theirs, derive from, such as from the history of the store.
+// found is false when it cannot tell.
+type BaseFinder func(file string, theirs []byte) (base []byte, found bool, err error)
+
+var baseFinder BaseFinder
+
+// FindBasesWith sets where ResolveConflict looks for the common ancestor of a
+// conflict copy and its original
+func FindBasesWith(finder BaseFinder) {
+	baseFinder = finder
+}
+
+// ResolveConflict merges the conflict copy at copyPath into its original and
+// removes the copy. Without a BaseFinder that knows the common ancestor, the
+// merge is based on tombstones, the contents deleted from the original, so
+// what was deleted on one side does not come back from the other.
+func ResolveConflict(copyPath string, merge Merger, tombstones func(original string) ([]byte, error)) error {
+	originalPath := path.Join(path.Dir(copyPath), ConflictOriginal(path.Base(copyPath)))
+
+	theirs, err := ReadFile(copyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read conflict copy: %w", err)
+	}
+
+	ours, err := ReadFile(originalPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", originalPath, err)
+	}
+
+	var base []byte
+	found := false
+	if baseFinder != nil {
+		if base, found, err = baseFinder(originalPath, theirs); err != nil {
+			return fmt.Errorf("failed to find the common version of %s: %w", originalPath, err)
+		}
+	}
+	if !found {
+		if base, err = tombstones(originalPath); err != nil {
+			return err
+		}
+	}
+
+	merged, err := merge(base, ours, theirs)
+	if err != nil {
+		return fmt.Errorf("failed to merge conflict copy: %w", err)
+	}
+
+	if err = WriteFile(originalPath, merged); err != nil {
+		return err
+	}
+	if err = os.Remove(copyPath); err != nil {
+		return err
+	}
+
+	Changed(fmt.Sprintf("merge conflict copy %s", path.Base(copyPath)))
+	return nil
+}
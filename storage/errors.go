@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ParseError is a file of the store that could not be understood. Line is 0
+// when the problem is not on a particular line.
+type ParseError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (p ParseError) Error() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Err)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Err)
+}
+
+func (p ParseError) Unwrap() error {
+	return p.Err
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+// yamlSyntaxError is how the yaml package words syntax errors
+var yamlSyntaxError = regexp.MustCompile(`^yaml: line \d+: (.*)$`)
+
+// YAMLError is a ParseError for a YAML decoding error, taking the line from
+// the message as the yaml package does not expose it
+func YAMLError(file string, err error) ParseError {
+	parseError := ParseError{File: file, Err: err}
+	if match := yamlLine.FindStringSubmatch(err.Error()); match != nil {
+		parseError.Line, _ = strconv.Atoi(match[1])
+	}
+	// syntax errors are plain errors, so nothing is lost by not repeating the line
+	if match := yamlSyntaxError.FindStringSubmatch(err.Error()); match != nil {
+		parseError.Err = errors.New(match[1])
+	}
+	return parseError
+}
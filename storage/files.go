@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"noted/crypt"
+	"os"
+	"path"
+)
+
+// Decode returns the plaintext of a file's contents, decrypting them when
+// they are encrypted
+func Decode(data []byte) ([]byte, error) {
+	if !crypt.IsEncrypted(data) {
+		return data, nil
+	}
+
+	key, err := crypt.Key()
+	if err != nil {
+		return nil, err
+	}
+	return crypt.Decrypt(key, data)
+}
+
+// Encode prepares plaintext for writing, encrypting it when the store is encrypted
+func Encode(data []byte) ([]byte, error) {
+	if !crypt.Enabled() {
+		return data, nil
+	}
+
+	key, err := crypt.Key()
+	if err != nil {
+		return nil, err
+	}
+	return crypt.Encrypt(key, data)
+}
+
+func ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+func WriteFile(name string, data []byte) error {
+	encoded, err := Encode(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, encoded, 0644)
+}
+
+func AppendFile(name string, data []byte) error {
+	if !crypt.Enabled() {
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = file.Write(data)
+		return err
+	}
+
+	// an encrypted file has to be rewritten as a whole
+	existing, err := ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return WriteFile(name, append(existing, data...))
+}
+
+// Recode rewrites the given files from one key to another. A nil from key
+// means the files are plaintext, a nil to key decrypts them. Every file is
+// recoded into a temporary file next to it before any is renamed into place,
+// so a file that fails to recode leaves all of them untouched.
+func Recode(files []string, from []byte, to []byte) error {
+	recoded := make([]string, 0, len(files))
+	defer func() {
+		for _, name := range recoded {
+			os.Remove(recodePath(name))
+		}
+	}()
+
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		if crypt.IsEncrypted(data) {
+			if from == nil {
+				// already encrypted
+				continue
+			}
+			if data, err = crypt.Decrypt(from, data); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		if to != nil {
+			if data, err = crypt.Encrypt(to, data); err != nil {
+				return err
+			}
+		}
+
+		recoded = append(recoded, name)
+		if err = os.WriteFile(recodePath(name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	for len(recoded) > 0 {
+		if err := os.Rename(recodePath(recoded[0]), recoded[0]); err != nil {
+			return err
+		}
+		recoded = recoded[1:]
+	}
+	return nil
+}
+
+// recodePath is the temporary file a file is recoded into, hidden so it is
+// not mistaken for notes
+func recodePath(name string) string {
+	return path.Join(path.Dir(name), "."+path.Base(name)+".recode")
+}
+
+// Files lists the regular files in the given directories, skipping
+// directories that do not exist
+func Files(directories ...string) ([]string, error) {
+	files := make([]string, 0)
+	for _, directory := range directories {
+		entries, err := os.ReadDir(directory)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				files = append(files, path.Join(directory, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
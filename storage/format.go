@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// Format is a kind of file of the store, such as the monthly task files.
+// Files record the version of the format they were written in, so a build
+// changing the format can still read what older ones wrote.
+type Format struct {
+	Name      string
+	Directory func() string
+	// Version reads the version of the format contents are in, 0 for files
+	// written before versions were recorded
+	Version func(data []byte) (int, error)
+	// Migrations upgrade contents, Migrations[i] from version i to i+1, so
+	// the current version is the number of migrations
+	Migrations []Migration
+}
+
+type Migration struct {
+	Description string
+	Apply       func(data []byte) ([]byte, error)
+}
+
+// VersionError is a file written by a newer build of noted
+type VersionError struct {
+	Format  string
+	Version int
+	Current int
+}
+
+func (v VersionError) Error() string {
+	return fmt.Sprintf("%s file version %d is newer than version %d this noted knows, upgrade noted", v.Format, v.Version, v.Current)
+}
+
+func (f Format) Current() int {
+	return len(f.Migrations)
+}
+
+// Upgrade brings contents to the current version of the format, returning
+// the version they were in
+func (f Format) Upgrade(data []byte) ([]byte, int, error) {
+	version, err := f.Version(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version > f.Current() {
+		return nil, version, VersionError{Format: f.Name, Version: version, Current: f.Current()}
+	}
+
+	for _, migration := range f.Migrations[version:] {
+		if data, err = migration.Apply(data); err != nil {
+			return nil, version, fmt.Errorf("failed to %s: %w", migration.Description, err)
+		}
+	}
+	return data, version, nil
+}
+
+var formats []Format
+
+func RegisterFormat(format Format) {
+	formats = append(formats, format)
+}
+
+// Upgrade is a file brought, or to be brought, to the current version of its
+// format
+type Upgrade struct {
+	File       string
+	From       int
+	To         int
+	Migrations []string
+}
+
+// Migrate upgrades every file of the registered formats written in an older
+// version, unless dryRun is set, and returns what it did or would do. Files
+// that fail to upgrade are reported together in the error, the others are
+// still upgraded.
+func Migrate(dryRun bool) ([]Upgrade, error) {
+	upgrades := make([]Upgrade, 0)
+	problems := make([]error, 0)
+
+	for _, format := range formats {
+		files, err := Files(format.Directory())
+		if err != nil {
+			return upgrades, err
+		}
+
+		for _, file := range files {
+			if IsConflictCopy(path.Base(file)) {
+				continue
+			}
+			data, err := ReadFile(file)
+			if err != nil {
+				problems = append(problems, err)
+				continue
+			}
+
+			upgraded, version, err := format.Upgrade(data)
+			if err != nil {
+				problems = append(problems, ParseError{File: file, Err: err})
+				continue
+			}
+			if version == format.Current() {
+				continue
+			}
+
+			upgrade := Upgrade{File: file, From: version, To: format.Current()}
+			for _, migration := range format.Migrations[version:] {
+				upgrade.Migrations = append(upgrade.Migrations, migration.Description)
+			}
+			if !dryRun {
+				if err = WriteFile(file, upgraded); err != nil {
+					return upgrades, err
+				}
+			}
+			upgrades = append(upgrades, upgrade)
+		}
+	}
+
+	if len(upgrades) > 0 && !dryRun {
+		Changed(fmt.Sprintf("migrate %d files", len(upgrades)))
+	}
+	return upgrades, errors.Join(problems...)
+}
@@ -0,0 +1,17 @@
+package storage
+
+// ChangeHook is told about every mutation of the note store, with a short
+// human readable description of what changed
+type ChangeHook func(description string)
+
+var changeHooks []ChangeHook
+
+func OnChange(hook ChangeHook) {
+	changeHooks = append(changeHooks, hook)
+}
+
+func Changed(description string) {
+	for _, hook := range changeHooks {
+		hook(description)
+	}
+}
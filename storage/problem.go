@@ -0,0 +1,20 @@
+package storage
+
+import "fmt"
+
+// Problem is something wrong with a file of the store, found by an integrity
+// check. Line is 0 when the problem is not on a particular line.
+type Problem struct {
+	File        string
+	Line        int
+	Description string
+	// Fixable is set when a repair can take care of the problem
+	Fixable bool
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Description)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Description)
+}
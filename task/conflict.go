@@ -0,0 +1,17 @@
+package task
+
+import (
+	"go.uber.org/zap"
+	"noted/logging"
+	"noted/storage"
+)
+
+// ResolveConflict merges the conflict copy at copyPath into its original task
+// file and removes the copy
+func ResolveConflict(copyPath string) error {
+	if err := storage.ResolveConflict(copyPath, MergeFiles, tombstones); err != nil {
+		logging.Logger.Error("failed to merge conflict copy", zap.String("file", copyPath), zap.Error(err))
+		return err
+	}
+	return nil
+}
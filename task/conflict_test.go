@@ -0,0 +1,74 @@
+package task
+
+import (
+	"github.com/spf13/viper"
+	config "noted/config"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// without a common version at hand, the trash tells what was deleted
+func TestResolveConflictKeepsDeletions(t *testing.T) {
+	store := t.TempDir()
+	viper.Set(config.ConfigStorageDir, store)
+	viper.Set(config.ConfigTaskPrefix, "task")
+	created := time.Date(2023, time.September, 1, 9, 0, 0, 0, time.Local)
+
+	add := func(title string) Task {
+		added, err := AddTask(Task{Task: title, CreatedAt: created})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return added
+	}
+	deleted := add("deleted here")
+	add("kept")
+	file := path.Join(store, "task", "2023-September.yaml")
+	shared, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the other machine adds a task to the version both had
+	add("added there")
+	theirs, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(file, shared, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// while this one deletes a task
+	if err = DeleteTask(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	copyPath := path.Join(store, "task", "2023-September (conflicted copy 2023-10-01).yaml")
+	if err = os.WriteFile(copyPath, theirs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ResolveConflict(copyPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(copyPath); err == nil {
+		t.Error("conflict copy left behind")
+	}
+
+	tasks, err := ListTasks(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Task)
+	}
+	slices.Sort(titles)
+	if strings.Join(titles, ", ") != "added there, kept" {
+		t.Errorf("tasks after merging are %v, want the added and the kept one", titles)
+	}
+}
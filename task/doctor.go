@@ -0,0 +1,200 @@
+package task
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"noted/storage"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// taskFileName matches the monthly files AddTask writes, such as 2023-September.yaml
+var taskFileName = regexp.MustCompile(`^\d{4}-([A-Za-z]+)\.yaml$`)
+
+// fileName is the file of the month a task created at is stored in
+func fileName(at time.Time) string {
+	return fmt.Sprintf("%d-%s.yaml", at.Year(), at.Month())
+}
+
+// Check looks for unparsable task files, duplicate ids, tasks stored in the
+// file of another month and invalid statuses. With repair, it keeps what can
+// be parsed of broken files, drops exact copies, gives other duplicates a new
+// id, moves tasks to the file of their month and resets invalid statuses.
+func Check(repair bool) ([]storage.Problem, error) {
+	taskPath := Directory()
+	files, err := storage.Files(taskPath)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]storage.Problem, 0)
+	report := func(file string, line int, fixable bool, format string, args ...any) int {
+		problems = append(problems, storage.Problem{
+			File:        file,
+			Line:        line,
+			Description: fmt.Sprintf(format, args...),
+			Fixable:     fixable,
+		})
+		return len(problems) - 1
+	}
+
+	contents := make(map[string]*EntryFile)
+	misnamed := make(map[string]int)
+	// files that cannot be parsed at all, so nothing is moved into them
+	broken := make(map[string]bool)
+	changed := make(map[string]bool)
+	checked := make([]string, 0, len(files))
+
+	for _, file := range files {
+		name := path.Base(file)
+		if storage.IsConflictCopy(name) {
+			report(file, 0, false, "conflict copy, run `noted doctor merge-conflicts` to merge it")
+			continue
+		}
+		if match := taskFileName.FindStringSubmatch(name); match == nil || !isMonth(match[1]) {
+			misnamed[file] = report(file, 0, true, "not named after a month, like 2023-September.yaml")
+		}
+
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return problems, err
+		}
+		entries, err := decode(data)
+		var version storage.VersionError
+		if errors.As(err, &version) {
+			report(file, 0, false, "%s", err)
+			broken[file] = true
+			continue
+		} else if err != nil {
+			parseErr := storage.YAMLError(file, err)
+			salvaged, lines, ok := salvage(data)
+			if !ok {
+				report(file, parseErr.Line, false, "unparsable YAML: %s", parseErr.Err)
+				broken[file] = true
+				continue
+			}
+			report(file, parseErr.Line, true, "unparsable YAML: %s, the %d tasks of the first %d lines can be kept", parseErr.Err, len(salvaged.Entries), lines)
+			entries = salvaged
+			changed[file] = true
+		}
+		contents[file] = &entries
+		checked = append(checked, file)
+	}
+
+	seen := make(map[string]Entry)
+	seenIn := make(map[string]string)
+	moved := make(map[string][]Entry)
+
+	for _, file := range checked {
+		kept := make([]Entry, 0, len(contents[file].Entries))
+		for _, entry := range contents[file].Entries {
+			if entry.Status > Done {
+				status := Status(ToDo)
+				if entry.CompletedAt != nil {
+					status = Done
+				}
+				report(file, 0, true, "task %q has invalid status %d, it becomes %s", entry.Task, entry.Status, status.AsString())
+				entry.Status = status
+				changed[file] = true
+			}
+
+			if entry.Id == "" {
+				report(file, 0, true, "task %q has no id", entry.Task)
+				entry.Id = uuid.NewString()
+				changed[file] = true
+			} else if first, ok := seen[entry.Id]; ok {
+				if reflect.DeepEqual(first, entry) {
+					report(file, 0, true, "task %q is a copy of the one in %s", entry.Task, path.Base(seenIn[entry.Id]))
+					changed[file] = true
+					continue
+				}
+				report(file, 0, true, "task %q has the same id %s as %q in %s", entry.Task, entry.Id, first.Task, path.Base(seenIn[entry.Id]))
+				entry.Id = uuid.NewString()
+				changed[file] = true
+			}
+			seen[entry.Id] = entry
+			seenIn[entry.Id] = file
+
+			if !entry.CreatedAt.IsZero() {
+				target := path.Join(taskPath, fileName(entry.CreatedAt))
+				if target != file && !broken[target] {
+					if _, ok := misnamed[file]; !ok {
+						report(file, 0, true, "task %q was created in %s and belongs in %s", entry.Task, entry.CreatedAt.Format("January 2006"), path.Base(target))
+					}
+					moved[target] = append(moved[target], entry)
+					changed[file] = true
+					changed[target] = true
+					continue
+				}
+			}
+			kept = append(kept, entry)
+		}
+		contents[file].Entries = kept
+	}
+
+	// a misnamed file is only dealt with once every task has moved out of it
+	for file, problem := range misnamed {
+		if contents[file] == nil || len(contents[file].Entries) > 0 {
+			problems[problem].Fixable = false
+		}
+	}
+
+	if !repair || len(changed) == 0 {
+		return problems, nil
+	}
+
+	for target, entries := range moved {
+		if contents[target] == nil {
+			contents[target] = &EntryFile{}
+		}
+		contents[target].Entries = append(contents[target].Entries, entries...)
+	}
+
+	names := make([]string, 0, len(changed))
+	for file := range changed {
+		names = append(names, file)
+	}
+	slices.Sort(names)
+	for _, file := range names {
+		if len(contents[file].Entries) == 0 {
+			if err = os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return problems, err
+			}
+			continue
+		}
+		output, err := encode(*contents[file])
+		if err != nil {
+			return problems, err
+		}
+		if err = storage.WriteFile(file, output); err != nil {
+			return problems, err
+		}
+	}
+
+	storage.Changed("repair task files")
+	return problems, nil
+}
+
+func isMonth(name string) bool {
+	_, err := time.Parse("January", name)
+	return err == nil
+}
+
+// salvage finds the longest run of leading lines that parses and holds
+// tasks, which recovers files left with the tail of a longer version after
+// an interrupted or shorter rewrite. It returns how many lines were kept.
+func salvage(data []byte) (EntryFile, int, bool) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for n := len(lines) - 1; n > 0; n-- {
+		if entries, err := decode(bytes.Join(lines[:n], nil)); err == nil && len(entries.Entries) > 0 {
+			return entries, n, true
+		}
+	}
+	return EntryFile{}, 0, false
+}
@@ -0,0 +1,105 @@
+package task
+
+import (
+	"github.com/spf13/viper"
+	config "noted/config"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func writeEntries(t *testing.T, file string, entries []Entry, tail string) {
+	t.Helper()
+	data, err := encode(EntryFile{Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(file, append(data, tail...), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readEntries(t *testing.T, file string) []Entry {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := decode(data)
+	if err != nil {
+		t.Fatalf("%s after repairing: %s", path.Base(file), err)
+	}
+	return contents.Entries
+}
+
+func TestCheckRepairs(t *testing.T) {
+	store := t.TempDir()
+	viper.Set(config.ConfigStorageDir, store)
+	viper.Set(config.ConfigTaskPrefix, "task")
+	directory := path.Join(store, "task")
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	september := path.Join(directory, "2023-September.yaml")
+	october := path.Join(directory, "2023-October.yaml")
+
+	inSeptember := time.Date(2023, time.September, 1, 9, 0, 0, 0, time.UTC)
+	inOctober := time.Date(2023, time.October, 2, 9, 0, 0, 0, time.UTC)
+	completed := inSeptember.Add(time.Hour)
+	invalid := Entry{Id: "11111111-1111-4111-8111-111111111111", CreatedAt: inSeptember, Task: "invalid status", Status: 9, CompletedAt: &completed}
+	copied := Entry{Id: "22222222-2222-4222-8222-222222222222", CreatedAt: inSeptember, Task: "copied"}
+	misplaced := Entry{Id: "33333333-3333-4333-8333-333333333333", CreatedAt: inOctober, Task: "misplaced"}
+	clash := Entry{Id: invalid.Id, CreatedAt: inOctober, Task: "same id"}
+
+	// the tail of a longer version after a shorter rewrite
+	writeEntries(t, september, []Entry{invalid, copied, misplaced}, "      task: left over\n    - id: [\n")
+	writeEntries(t, october, []Entry{copied, clash}, "")
+
+	problems, err := Check(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		if !problem.Fixable {
+			t.Errorf("%s cannot be fixed", problem)
+		}
+	}
+	if len(problems) != 6 {
+		t.Errorf("found %d problems, want 6: %v", len(problems), problems)
+	}
+
+	// files are checked in order, so the first seen of a pair is the one
+	// in 2023-October.yaml
+	want := map[string][]string{
+		september: {"invalid status", "copied"},
+		october:   {"same id", "misplaced"},
+	}
+	tasks := make(map[string]Entry)
+	for file, titles := range want {
+		entries := readEntries(t, file)
+		if len(entries) != len(titles) {
+			t.Fatalf("%s holds %+v, want %v", path.Base(file), entries, titles)
+		}
+		for i, entry := range entries {
+			if entry.Task != titles[i] {
+				t.Errorf("%s holds %q, want %q", path.Base(file), entry.Task, titles[i])
+			}
+			tasks[entry.Task] = entry
+		}
+	}
+
+	if tasks["invalid status"].Status != Done {
+		t.Errorf("invalid status with a completion became %s, want DONE", tasks["invalid status"].Status.AsString())
+	}
+	if id := tasks["invalid status"].Id; id == clash.Id || id == "" {
+		t.Errorf("second task with the id %s kept %q, want a new id", clash.Id, id)
+	}
+	if tasks["same id"].Id != clash.Id {
+		t.Errorf("first task with the id %s has %q, want it kept", clash.Id, tasks["same id"].Id)
+	}
+
+	if problems, err = Check(false); err != nil || len(problems) != 0 {
+		t.Errorf("after repairing found %v, %v, want nothing", problems, err)
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
-	"gopkg.in/yaml.v3"
+	"noted/backup"
 	config "noted/config"
 	"noted/logging"
+	"noted/storage"
 	"os"
 	"path"
+	"strings"
 	"time"
 )
 
@@ -20,10 +22,24 @@ type NotFoundError struct {
 }
 
 func (n NotFoundError) Error() string {
+	if n.File == "" {
+		return fmt.Sprintf("task %s not found", n.Task)
+	}
 	return fmt.Sprintf("%s:%s", n.File, n.Task)
 }
 
+type AmbiguousIdError struct {
+	Id      string
+	Matches int
+}
+
+func (a AmbiguousIdError) Error() string {
+	return fmt.Sprintf("%s matches %d tasks", a.Id, a.Matches)
+}
+
 type EntryFile struct {
+	// Version is the version of the file format, see Format
+	Version int `yaml:"version"`
 	Entries []Entry
 }
 
@@ -35,6 +51,32 @@ type Entry struct {
 	Task         string
 	Detail       string
 	Status       Status
+	// Priority is a single letter, A being the most important
+	Priority    string     `yaml:"priority,omitempty"`
+	CompletedAt *time.Time `yaml:"completed_at,omitempty"`
+	Projects    []string   `yaml:"projects,omitempty"`
+	Contexts    []string   `yaml:"contexts,omitempty"`
+	// Parent is the id of the task this one is a subtask of
+	Parent string  `yaml:"parent,omitempty"`
+	Clocks []Clock `yaml:"clocks,omitempty"`
+	// UID identifies the task in external calendars it was imported from
+	UID string `yaml:"uid,omitempty"`
+	// Modified records when each field was last changed, keyed by field name
+	Modified map[string]time.Time `yaml:"modified,omitempty"`
+}
+
+// Clock is a span of time spent working on a task, End is nil while the
+// clock is running
+type Clock struct {
+	Start time.Time
+	End   *time.Time `yaml:"end,omitempty"`
+}
+
+func (c Clock) Duration() time.Duration {
+	if c.End == nil {
+		return time.Since(c.Start)
+	}
+	return c.End.Sub(c.Start)
 }
 
 func (t Entry) ToTask(file string) Task {
@@ -47,6 +89,14 @@ func (t Entry) ToTask(file string) Task {
 		Task:         t.Task,
 		Detail:       t.Detail,
 		Status:       t.Status,
+		Priority:     t.Priority,
+		CompletedAt:  t.CompletedAt,
+		Projects:     t.Projects,
+		Contexts:     t.Contexts,
+		Parent:       t.Parent,
+		Clocks:       t.Clocks,
+		UID:          t.UID,
+		Modified:     t.Modified,
 	}
 }
 
@@ -59,6 +109,14 @@ type Task struct {
 	Task         string
 	Detail       string
 	Status       Status
+	Priority     string
+	CompletedAt  *time.Time
+	Projects     []string
+	Contexts     []string
+	Parent       string
+	Clocks       []Clock
+	UID          string
+	Modified     map[string]time.Time
 }
 
 func (t Task) Title() string {
@@ -77,6 +135,16 @@ func (t Task) Matches(entry Entry) bool {
 	return t.Id == entry.Id
 }
 
+// Open reports whether the task still needs work
+func (t Task) Open() bool {
+	return t.Status != Done && t.Status != Cancelled
+}
+
+// Overdue reports whether an open task was due before at
+func (t Task) Overdue(at time.Time) bool {
+	return t.Open() && t.DueAt != nil && t.DueAt.Before(at)
+}
+
 func (t Task) ToEntry() Entry {
 	return Entry{
 		Id:           t.Id,
@@ -86,6 +154,14 @@ func (t Task) ToEntry() Entry {
 		Task:         t.Task,
 		Detail:       t.Detail,
 		Status:       t.Status,
+		Priority:     t.Priority,
+		CompletedAt:  t.CompletedAt,
+		Projects:     t.Projects,
+		Contexts:     t.Contexts,
+		Parent:       t.Parent,
+		Clocks:       t.Clocks,
+		UID:          t.UID,
+		Modified:     t.Modified,
 	}
 }
 
@@ -119,79 +195,109 @@ func (s Status) AsString() string {
 	}
 }
 
-func CreateTask(task string, detail string, due *time.Time) error {
-	createdTime := time.Now()
-	prefix := viper.GetString(config.ConfigTaskPrefix)
-	storageDir := viper.GetString(config.ConfigStorageDir)
-	taskPath := path.Join(storageDir, prefix)
-	taskFilePath := path.Join(storageDir, prefix, fmt.Sprintf("%d-%s.yaml", createdTime.Year(), createdTime.Month()))
-	var taskFile *os.File
-	var err error
-	var taskEntries EntryFile
+func ParseStatus(value string) (Status, error) {
+	for s := ToDo; s <= Done; s++ {
+		if strings.EqualFold(Status(s).AsString(), value) {
+			return Status(s), nil
+		}
+	}
+	return ToDo, fmt.Errorf("unknown status %q", value)
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func ParseDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &parsed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unrecognized date %q, expected YYYY-MM-DD or YYYY-MM-DD HH:MM", value)
+}
+
+func CreateTask(task string, detail string, due *time.Time) (Task, error) {
+	return AddTask(Task{
+		DueAt:  due,
+		Task:   task,
+		Detail: detail,
+		Status: ToDo,
+	})
+}
+
+// AddTask stores a new task in the file of the month it was created in. A
+// missing Id or creation time is filled in.
+func AddTask(task Task) (Task, error) {
+	if task.Id == "" {
+		task.Id = uuid.NewString()
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	taskPath := Directory()
+	taskFilePath := path.Join(taskPath, fileName(task.CreatedAt))
+	taskEntries := EntryFile{
+		Entries: make([]Entry, 0),
+	}
 
 	if _, err := os.Stat(taskPath); errors.Is(err, os.ErrNotExist) {
 		if err = os.MkdirAll(taskPath, 0755); err != nil {
 			logging.Logger.Error("failed to create task path", zap.Error(err))
-			return err
+			return Task{}, err
 		}
 	}
 
-	if _, err := os.Stat(taskFilePath); errors.Is(err, os.ErrNotExist) {
-		taskFile, err = os.Create(taskFilePath)
-		taskEntries = EntryFile{
-			Entries: make([]Entry, 0),
-		}
-	} else {
-		taskFile, err = os.OpenFile(taskFilePath, os.O_WRONLY, os.ModeAppend)
-		data, err := os.ReadFile(taskFilePath)
-		if err != nil {
-			logging.Logger.Error("failed to read existing task file", zap.Error(err), zap.String("file", taskFilePath))
-			return err
-		}
-		if err = yaml.Unmarshal(data, &taskEntries); err != nil {
+	if data, err := storage.ReadFile(taskFilePath); err == nil {
+		if taskEntries, err = decodeFile(taskFilePath, data); err != nil {
 			logging.Logger.Error("failed to unmarshal existing task file", zap.Error(err), zap.String("file", taskFilePath))
-			return err
+			return Task{}, err
 		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		logging.Logger.Error("failed to read existing task file", zap.Error(err), zap.String("file", taskFilePath))
+		return Task{}, err
 	}
 
+	entry := complete(task.ToEntry(), time.Now())
+	taskEntries.Entries = append(taskEntries.Entries, entry)
+
+	output, err := encode(taskEntries)
 	if err != nil {
-		logging.Logger.Error("failed to create/open task file", zap.Error(err), zap.String("file", taskFilePath))
-		return err
+		logging.Logger.Error("failed to marshal task file data", zap.Error(err))
+		return Task{}, err
 	}
 
-	defer taskFile.Close()
-
-	taskEntries.Entries = append(taskEntries.Entries, Entry{
-		Id:           uuid.NewString(),
-		CreatedAt:    createdTime,
-		DueAt:        due,
-		ScheduledFor: nil,
-		Task:         task,
-		Detail:       detail,
-		Status:       ToDo,
-	})
-
-	if output, err := yaml.Marshal(taskEntries); err != nil {
-		logging.Logger.Error("failed to marshal task file data", zap.Error(err))
-		return err
-	} else {
-		_, err = taskFile.Write(output)
+	if err = storage.WriteFile(taskFilePath, output); err != nil {
+		logging.Logger.Error("failed to write task file", zap.Error(err), zap.String("file", taskFilePath))
+		return Task{}, err
 	}
 
-	return err
+	storage.Changed(fmt.Sprintf("create task %q", task.Task))
+	return entry.ToTask(taskFilePath), nil
 }
 
 func UpdateTask(task Task) error {
-	if data, err := os.ReadFile(task.File); err != nil {
-		logging.Logger.Error("task's file is not found", zap.String("file", task.File))
+	if data, err := storage.ReadFile(task.File); err != nil {
+		logging.Logger.Error("failed to read task's file", zap.String("file", task.File), zap.Error(err))
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
 		return NotFoundError{
 			File: task.File,
 			Task: task.Task,
 		}
 	} else {
-		var contents EntryFile
-
-		if err = yaml.Unmarshal(data, &contents); err != nil {
+		contents, err := decodeFile(task.File, data)
+		if err != nil {
 			logging.Logger.Error("failed to parse task file", zap.String("file", task.File), zap.Error(err))
 			return err
 		}
@@ -200,7 +306,7 @@ func UpdateTask(task Task) error {
 		for i, entry := range contents.Entries {
 			if task.Matches(entry) {
 				found = true
-				contents.Entries[i] = task.ToEntry()
+				contents.Entries[i] = touch(entry, complete(task.ToEntry(), time.Now()), time.Now())
 			}
 		}
 
@@ -212,53 +318,150 @@ func UpdateTask(task Task) error {
 			}
 		}
 
-		output, err := yaml.Marshal(contents)
+		output, err := encode(contents)
 		if err != nil {
 			logging.Logger.Error("failed to marshal tasks YAML", zap.Error(err), zap.String("file", task.File))
 			return err
 		}
 
-		taskFile, err := os.OpenFile(task.File, os.O_WRONLY, os.ModeAppend)
-		if err != nil {
-			logging.Logger.Error("failed to marshal tasks YAML", zap.Error(err), zap.String("file", task.File))
+		if err = storage.WriteFile(task.File, output); err != nil {
+			logging.Logger.Error("failed to write task file", zap.Error(err), zap.String("file", task.File))
 			return err
 		}
 
-		_, err = taskFile.Write(output)
+		storage.Changed(fmt.Sprintf("update task %q (%s)", task.Task, task.Status.AsString()))
+		return nil
+	}
+}
+
+// DeleteTask moves a task from its file to the trash, after taking a
+// snapshot of the store
+func DeleteTask(task Task) error {
+	data, err := storage.ReadFile(task.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return NotFoundError{File: task.File, Task: task.Task}
+	} else if err != nil {
+		logging.Logger.Error("failed to read task's file", zap.String("file", task.File), zap.Error(err))
 		return err
 	}
+
+	contents, err := decodeFile(task.File, data)
+	if err != nil {
+		logging.Logger.Error("failed to parse task file", zap.String("file", task.File), zap.Error(err))
+		return err
+	}
+
+	remaining := make([]Entry, 0, len(contents.Entries))
+	deleted := make([]Entry, 0, 1)
+	for _, entry := range contents.Entries {
+		if task.Matches(entry) {
+			deleted = append(deleted, entry)
+		} else {
+			remaining = append(remaining, entry)
+		}
+	}
+
+	if len(deleted) == 0 {
+		return NotFoundError{File: task.File, Task: task.Task}
+	}
+	contents.Entries = remaining
+
+	if _, err = backup.Create("delete"); err != nil {
+		logging.Logger.Error("failed to snapshot the store", zap.Error(err))
+		return fmt.Errorf("failed to back up before deleting: %w", err)
+	}
+	for _, entry := range deleted {
+		if err = moveToTrash(entry, task.File); err != nil {
+			logging.Logger.Error("failed to move task to the trash", zap.Error(err), zap.String("task", entry.Id))
+			return err
+		}
+	}
+
+	output, err := encode(contents)
+	if err != nil {
+		logging.Logger.Error("failed to marshal tasks YAML", zap.Error(err), zap.String("file", task.File))
+		return err
+	}
+
+	if err = storage.WriteFile(task.File, output); err != nil {
+		logging.Logger.Error("failed to write task file", zap.Error(err), zap.String("file", task.File))
+		return err
+	}
+
+	storage.Changed(fmt.Sprintf("delete task %q", task.Task))
+	return nil
 }
 
-func ListTasks(includeCompleted bool) []Task {
-	storageDir := viper.GetString(config.ConfigStorageDir)
-	prefixDir := viper.GetString(config.ConfigTaskPrefix)
-	taskPath := path.Join(storageDir, prefixDir)
-	files, err := os.ReadDir(taskPath)
+func Directory() string {
+	return path.Join(viper.GetString(config.ConfigStorageDir), viper.GetString(config.ConfigTaskPrefix))
+}
 
+// ListTasks reads every task file. Files that cannot be read or parsed are
+// skipped and reported together in the error, next to the tasks of the other
+// files, so callers can choose to carry on with what was readable. Done tasks
+// are left out unless includeCompleted is set.
+func ListTasks(includeCompleted bool) ([]Task, error) {
+	taskPath := Directory()
+	files, err := os.ReadDir(taskPath)
 	if err != nil {
-		logging.Logger.Fatal("failed to read from task directory", zap.Error(err), zap.String("directory", taskPath))
+		return nil, fmt.Errorf("failed to read task directory: %w", err)
 	}
 
 	tasks := make([]Task, 0)
+	problems := make([]error, 0)
 
 	for _, file := range files {
-		if data, err := os.ReadFile(path.Join(taskPath, file.Name())); err != nil {
-			logging.Logger.Error("failed to read task file", zap.String("file", file.Name()), zap.Error(err))
+		if file.IsDir() {
+			continue
+		}
+		if storage.IsConflictCopy(file.Name()) {
+			logging.Logger.Warn("skipping conflict copy, run `noted doctor merge-conflicts` to merge it", zap.String("file", file.Name()))
+			continue
+		}
+		filePath := path.Join(taskPath, file.Name())
+		if data, err := storage.ReadFile(filePath); err != nil {
+			problems = append(problems, err)
+		} else if contents, err := decodeFile(filePath, data); err != nil {
+			problems = append(problems, err)
 		} else {
-			var contents EntryFile
-			if err = yaml.Unmarshal(data, &contents); err != nil {
-				logging.Logger.Error("failed to unmarshal yaml for task file", zap.Error(err), zap.String("file", file.Name()))
-			} else {
-				for _, entry := range contents.Entries {
-					if entry.Status == Done && includeCompleted {
-						tasks = append(tasks, entry.ToTask(path.Join(taskPath, file.Name())))
-					} else {
-						tasks = append(tasks, entry.ToTask(path.Join(taskPath, file.Name())))
-					}
+			for _, entry := range contents.Entries {
+				if includeCompleted || entry.Status != Done {
+					tasks = append(tasks, entry.ToTask(filePath))
 				}
 			}
 		}
 	}
 
-	return tasks
+	return tasks, errors.Join(problems...)
+}
+
+// FindTask looks a task up by its id or a unique prefix of it. Unreadable
+// task files only fail the lookup when the task is not among the others.
+func FindTask(id string) (Task, error) {
+	tasks, listErr := ListTasks(true)
+	if tasks == nil {
+		return Task{}, listErr
+	}
+
+	var matched []Task
+	for _, t := range tasks {
+		if t.Id == id {
+			return t, nil
+		}
+		if strings.HasPrefix(t.Id, id) {
+			matched = append(matched, t)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		if listErr != nil {
+			return Task{}, listErr
+		}
+		return Task{}, NotFoundError{Task: id}
+	case 1:
+		return matched[0], nil
+	default:
+		return Task{}, AmbiguousIdError{Id: id, Matches: len(matched)}
+	}
 }
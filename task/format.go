@@ -0,0 +1,83 @@
+package task
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"noted/storage"
+	"strconv"
+)
+
+// Format is the format of the monthly task files, the version being the
+// top level version key
+var Format = storage.Format{
+	Name:      "task",
+	Directory: Directory,
+	Version: func(data []byte) (int, error) {
+		var header struct {
+			Version int `yaml:"version"`
+		}
+		err := yaml.Unmarshal(data, &header)
+		return header.Version, err
+	},
+	Migrations: []storage.Migration{
+		{Description: "record the format version", Apply: recordVersion},
+	},
+}
+
+func init() {
+	storage.RegisterFormat(Format)
+}
+
+// recordVersion adds the version key in front of the other keys, leaving
+// the rest of the file as it is
+func recordVersion(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	mapping := document.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping of entries")
+	}
+	version := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(1)}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == "version" {
+			mapping.Content[i+1] = version
+			return yaml.Marshal(&document)
+		}
+	}
+	mapping.Content = append([]*yaml.Node{{Kind: yaml.ScalarNode, Value: "version"}, version}, mapping.Content...)
+	return yaml.Marshal(&document)
+}
+
+// decode parses task file contents, upgrading older versions of the format
+func decode(data []byte) (EntryFile, error) {
+	var contents EntryFile
+	data, _, err := Format.Upgrade(data)
+	if err != nil {
+		return contents, err
+	}
+	err = yaml.Unmarshal(data, &contents)
+	return contents, err
+}
+
+// decodeFile is decode for the file at name, with errors pointing into it
+func decodeFile(name string, data []byte) (EntryFile, error) {
+	contents, err := decode(data)
+	var version storage.VersionError
+	if errors.As(err, &version) {
+		return contents, storage.ParseError{File: name, Err: err}
+	} else if err != nil {
+		return contents, storage.YAMLError(name, err)
+	}
+	return contents, nil
+}
+
+// encode writes task file contents in the current version of the format
+func encode(contents EntryFile) ([]byte, error) {
+	contents.Version = Format.Current()
+	return yaml.Marshal(contents)
+}
@@ -0,0 +1,83 @@
+package task
+
+// ImportResult counts what an import did with each record it was given
+type ImportResult struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Skipped   int
+}
+
+// Importer adds tasks read from other tools to the store. Records carry the
+// identifier the other tool knows them by, which is kept as the task's UID so
+// importing the same data again updates tasks instead of duplicating them.
+type Importer struct {
+	known  map[string]Task
+	Result ImportResult
+}
+
+// NewImporter fails when any task file is unreadable, as the tasks in it
+// would otherwise be imported again as duplicates
+func NewImporter() (*Importer, error) {
+	tasks, err := ListTasks(true)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]Task)
+	for _, t := range tasks {
+		// tasks exported by noted itself are identified by their id
+		known[t.Id] = t
+		if t.UID != "" {
+			known[t.UID] = t
+		}
+	}
+	return &Importer{known: known}, nil
+}
+
+// Known reports whether a task with the given identifier exists
+func (i *Importer) Known(uid string) bool {
+	_, ok := i.known[uid]
+	return ok && uid != ""
+}
+
+// Import applies a record to the task it identifies, creating the task when
+// it is not known yet, and returns the stored task. An empty uid always
+// creates a task.
+func (i *Importer) Import(uid string, apply func(*Task) error) (Task, error) {
+	if existing, ok := i.known[uid]; ok && uid != "" {
+		updated := existing
+		if err := apply(&updated); err != nil {
+			return existing, err
+		}
+		if len(Changes(existing, updated)) == 0 {
+			i.Result.Unchanged++
+			return existing, nil
+		}
+		if err := UpdateTask(updated); err != nil {
+			return existing, err
+		}
+		i.known[uid] = updated
+		i.Result.Updated++
+		return updated, nil
+	}
+
+	created := Task{UID: uid}
+	if err := apply(&created); err != nil {
+		return created, err
+	}
+	created, err := AddTask(created)
+	if err != nil {
+		return created, err
+	}
+	if uid != "" {
+		i.known[uid] = created
+	}
+	i.Result.Created++
+	return created, nil
+}
+
+// Skip counts a record that was deliberately not imported
+func (i *Importer) Skip() {
+	i.Result.Skipped++
+}
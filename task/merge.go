@@ -0,0 +1,205 @@
+package task
+
+import (
+	"reflect"
+	"time"
+)
+
+// Merge combines two divergent versions of a task file that share base as
+// their common ancestor. Entries are matched by Id and merged field by field:
+// a field changed on only one side keeps that change, and when both sides
+// changed the same field the most recently modified value wins, falling back
+// to ours. A deletion on one side is honoured unless the other side modified
+// the entry. An empty base merges two files without a known ancestor.
+func Merge(base EntryFile, ours EntryFile, theirs EntryFile) EntryFile {
+	baseEntries := indexEntries(base)
+	theirEntries := indexEntries(theirs)
+	merged := EntryFile{
+		Entries: make([]Entry, 0, len(ours.Entries)),
+	}
+	seen := make(map[string]bool)
+
+	for _, ourEntry := range ours.Entries {
+		seen[ourEntry.Id] = true
+		baseEntry, inBase := baseEntries[ourEntry.Id]
+		theirEntry, inTheirs := theirEntries[ourEntry.Id]
+
+		switch {
+		case inTheirs && inBase:
+			merged.Entries = append(merged.Entries, mergeEntry(baseEntry, ourEntry, theirEntry))
+		case inTheirs:
+			// added on both sides without a common ancestor
+			merged.Entries = append(merged.Entries, mergeEntry(Entry{}, ourEntry, theirEntry))
+		case inBase && reflect.DeepEqual(baseEntry, ourEntry):
+			// deleted by them and untouched by us
+		default:
+			merged.Entries = append(merged.Entries, ourEntry)
+		}
+	}
+
+	for _, theirEntry := range theirs.Entries {
+		if seen[theirEntry.Id] {
+			continue
+		}
+		baseEntry, inBase := baseEntries[theirEntry.Id]
+		if inBase && reflect.DeepEqual(baseEntry, theirEntry) {
+			// deleted by us and untouched by them
+			continue
+		}
+		merged.Entries = append(merged.Entries, theirEntry)
+	}
+
+	return merged
+}
+
+// MergeFiles is Merge for raw YAML documents, where an empty document stands
+// for a file that does not exist on that side
+func MergeFiles(base []byte, ours []byte, theirs []byte) ([]byte, error) {
+	var baseFile, ourFile, theirFile EntryFile
+
+	for _, document := range []struct {
+		data []byte
+		into *EntryFile
+	}{{base, &baseFile}, {ours, &ourFile}, {theirs, &theirFile}} {
+		var err error
+		if *document.into, err = decode(document.data); err != nil {
+			return nil, err
+		}
+	}
+
+	return encode(Merge(baseFile, ourFile, theirFile))
+}
+
+func indexEntries(file EntryFile) map[string]Entry {
+	entries := make(map[string]Entry, len(file.Entries))
+	for _, entry := range file.Entries {
+		entries[entry.Id] = entry
+	}
+	return entries
+}
+
+type entryMerge struct {
+	ours     Entry
+	theirs   Entry
+	modified map[string]time.Time
+}
+
+func mergeEntry(base Entry, ours Entry, theirs Entry) Entry {
+	m := &entryMerge{ours: ours, theirs: theirs, modified: make(map[string]time.Time)}
+	merged := Entry{
+		Id:           ours.Id,
+		CreatedAt:    mergeField(m, "created_at", base.CreatedAt, ours.CreatedAt, theirs.CreatedAt),
+		DueAt:        mergeField(m, "due_at", base.DueAt, ours.DueAt, theirs.DueAt),
+		ScheduledFor: mergeField(m, "scheduled_for", base.ScheduledFor, ours.ScheduledFor, theirs.ScheduledFor),
+		Task:         mergeField(m, "task", base.Task, ours.Task, theirs.Task),
+		Detail:       mergeField(m, "detail", base.Detail, ours.Detail, theirs.Detail),
+		Status:       mergeField(m, "status", base.Status, ours.Status, theirs.Status),
+		Priority:     mergeField(m, "priority", base.Priority, ours.Priority, theirs.Priority),
+		CompletedAt:  mergeField(m, "completed_at", base.CompletedAt, ours.CompletedAt, theirs.CompletedAt),
+		Projects:     mergeField(m, "projects", base.Projects, ours.Projects, theirs.Projects),
+		Contexts:     mergeField(m, "contexts", base.Contexts, ours.Contexts, theirs.Contexts),
+		Parent:       mergeField(m, "parent", base.Parent, ours.Parent, theirs.Parent),
+		Clocks:       mergeField(m, "clocks", base.Clocks, ours.Clocks, theirs.Clocks),
+		UID:          mergeField(m, "uid", base.UID, ours.UID, theirs.UID),
+	}
+	if len(m.modified) > 0 {
+		merged.Modified = m.modified
+	}
+	return merged
+}
+
+func mergeField[T any](m *entryMerge, field string, base T, ours T, theirs T) T {
+	oursChanged := !reflect.DeepEqual(base, ours)
+	theirsChanged := !reflect.DeepEqual(base, theirs)
+	oursModified, theirsModified := m.ours.Modified[field], m.theirs.Modified[field]
+
+	if theirsChanged && (!oursChanged || theirsModified.After(oursModified)) {
+		if !theirsModified.IsZero() {
+			m.modified[field] = theirsModified
+		}
+		return theirs
+	}
+	if !oursModified.IsZero() {
+		m.modified[field] = oursModified
+	}
+	return ours
+}
+
+// touch stamps the fields of updated that differ from current with now
+func touch(current Entry, updated Entry, now time.Time) Entry {
+	modified := make(map[string]time.Time, len(current.Modified))
+	for field, at := range current.Modified {
+		modified[field] = at
+	}
+
+	for _, field := range changedFields(current, updated) {
+		modified[field] = now
+	}
+
+	if len(modified) > 0 {
+		updated.Modified = modified
+	}
+	return updated
+}
+
+// Changes lists the fields of updated that differ from current
+func Changes(current Task, updated Task) []string {
+	return changedFields(current.ToEntry(), updated.ToEntry())
+}
+
+func changedFields(current Entry, updated Entry) []string {
+	changed := make([]string, 0)
+	for _, field := range []struct {
+		name    string
+		changed bool
+	}{
+		{"due_at", !sameTime(current.DueAt, updated.DueAt)},
+		{"scheduled_for", !sameTime(current.ScheduledFor, updated.ScheduledFor)},
+		{"task", current.Task != updated.Task},
+		{"detail", current.Detail != updated.Detail},
+		{"status", current.Status != updated.Status},
+		{"priority", current.Priority != updated.Priority},
+		{"completed_at", !sameTime(current.CompletedAt, updated.CompletedAt)},
+		{"projects", !reflect.DeepEqual(current.Projects, updated.Projects)},
+		{"contexts", !reflect.DeepEqual(current.Contexts, updated.Contexts)},
+		{"parent", current.Parent != updated.Parent},
+		{"clocks", !sameClocks(current.Clocks, updated.Clocks)},
+		{"uid", current.UID != updated.UID},
+	} {
+		if field.changed {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// complete records when an entry was finished, and forgets it again when the
+// entry is reopened
+func complete(entry Entry, now time.Time) Entry {
+	switch {
+	case entry.Status == Done && entry.CompletedAt == nil:
+		entry.CompletedAt = &now
+	case entry.Status != Done:
+		entry.CompletedAt = nil
+	}
+	return entry
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameClocks(a []Clock, b []Clock) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Equal(b[i].Start) || !sameTime(a[i].End, b[i].End) {
+			return false
+		}
+	}
+	return true
+}
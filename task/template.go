@@ -0,0 +1,186 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	config "noted/config"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+	offsetPattern      = regexp.MustCompile(`^([+-]?\d+)([hdw])$`)
+)
+
+type UnknownTemplateError struct {
+	Name string
+}
+
+func (u UnknownTemplateError) Error() string {
+	return fmt.Sprintf("no task template named %s in %s", u.Name, TemplateDirectory())
+}
+
+// MissingValueError is a placeholder in a template that was given no value
+type MissingValueError struct {
+	Name string
+}
+
+func (m MissingValueError) Error() string {
+	return fmt.Sprintf("no value for {{%s}}, pass it with --var %s=...", m.Name, m.Name)
+}
+
+// Template describes a task and its subtasks to create in one go, such as a
+// release checklist. Text may contain {{name}} placeholders and Due is an
+// offset from the moment the template is instantiated, e.g. 2d or 1w.
+type Template struct {
+	Task     string
+	Detail   string     `yaml:",omitempty"`
+	Due      string     `yaml:",omitempty"`
+	Subtasks []Template `yaml:",omitempty"`
+	// Vars holds default values for placeholders
+	Vars map[string]string `yaml:",omitempty"`
+}
+
+func TemplateDirectory() string {
+	return path.Join(viper.GetString(config.ConfigStorageDir), viper.GetString(config.ConfigTemplatePrefix), "tasks")
+}
+
+// Templates lists the names of the available task templates
+func Templates() ([]string, error) {
+	files, err := os.ReadDir(TemplateDirectory())
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if name, found := strings.CutSuffix(file.Name(), ".yaml"); found && !file.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func LoadTemplate(name string) (Template, error) {
+	var template Template
+
+	data, err := os.ReadFile(path.Join(TemplateDirectory(), name+".yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return template, UnknownTemplateError{Name: name}
+	} else if err != nil {
+		return template, err
+	}
+
+	if err = yaml.Unmarshal(data, &template); err != nil {
+		return template, fmt.Errorf("invalid task template %s: %w", name, err)
+	}
+	return template, nil
+}
+
+// Instantiate creates the template's task and, below it, its subtasks. vars
+// override the template's defaults, and {{date}} always expands to the day
+// of now. It returns every task created, parents before their subtasks.
+func (t Template) Instantiate(vars map[string]string, now time.Time) ([]Task, error) {
+	values := map[string]string{"date": now.Format("2006-01-02")}
+	for name, value := range t.Vars {
+		values[name] = value
+	}
+	for name, value := range vars {
+		values[name] = value
+	}
+
+	// check everything before the first task is written
+	if err := t.validate(values); err != nil {
+		return nil, err
+	}
+	return t.create(values, now, "")
+}
+
+func (t Template) validate(values map[string]string) error {
+	if t.Task == "" {
+		return errors.New("template task has no title")
+	}
+	for _, text := range []string{t.Task, t.Detail} {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			if _, ok := values[match[1]]; !ok {
+				return MissingValueError{Name: match[1]}
+			}
+		}
+	}
+	if _, err := ParseOffset(t.Due); err != nil {
+		return err
+	}
+	for _, subtask := range t.Subtasks {
+		if err := subtask.validate(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Template) create(values map[string]string, now time.Time, parent string) ([]Task, error) {
+	expand := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			return values[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+		})
+	}
+
+	draft := Task{
+		Task:   expand(t.Task),
+		Detail: expand(t.Detail),
+		Status: ToDo,
+		Parent: parent,
+	}
+	if offset, _ := ParseOffset(t.Due); offset != nil {
+		due := now.Add(*offset)
+		draft.DueAt = &due
+	}
+
+	created, err := AddTask(draft)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{created}
+	for _, subtask := range t.Subtasks {
+		subtasks, err := subtask.create(values, now, created.Id)
+		tasks = append(tasks, subtasks...)
+		if err != nil {
+			return tasks, err
+		}
+	}
+	return tasks, nil
+}
+
+// ParseOffset reads offsets such as 12h, 3d or -1w, returning nil for an
+// empty offset
+func ParseOffset(value string) (*time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	match := offsetPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("invalid offset %q, expected a number followed by h, d or w", value)
+	}
+
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, err
+	}
+	unit := map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[match[2]]
+	offset := time.Duration(count) * unit
+	return &offset, nil
+}
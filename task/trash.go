@@ -0,0 +1,84 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"noted/storage"
+	"noted/trash"
+	"os"
+)
+
+// TrashKind is the kind of trash items holding deleted tasks
+const TrashKind = "task"
+
+func init() {
+	trash.RegisterKind(TrashKind, restore)
+}
+
+func moveToTrash(entry Entry, file string) error {
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = trash.Add(TrashKind, entry.Task, file, string(data))
+	return err
+}
+
+// restore puts a deleted task back into the file it was deleted from,
+// unless a task with its id exists again
+func restore(item trash.Item) error {
+	var entry Entry
+	if err := yaml.Unmarshal([]byte(item.Data), &entry); err != nil {
+		return fmt.Errorf("invalid task in the trash: %w", err)
+	}
+
+	tasks, err := ListTasks(true)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if t.Id == entry.Id {
+			return fmt.Errorf("task %s exists already in %s", entry.Id, t.File)
+		}
+	}
+
+	var contents EntryFile
+	data, err := storage.ReadFile(item.Path())
+	if err == nil {
+		if contents, err = decodeFile(item.Path(), data); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	contents.Entries = append(contents.Entries, entry)
+
+	output, err := encode(contents)
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(item.Path(), output)
+}
+
+// tombstones is a task file of the tasks in the trash that were deleted from
+// file, standing in for the common ancestor of a conflict copy so the merge
+// keeps them deleted
+func tombstones(file string) ([]byte, error) {
+	items, err := trash.List()
+	if err != nil {
+		return nil, err
+	}
+	var contents EntryFile
+	for _, item := range items {
+		if item.Kind != TrashKind || item.Path() != file {
+			continue
+		}
+		var entry Entry
+		if err = yaml.Unmarshal([]byte(item.Data), &entry); err != nil {
+			return nil, fmt.Errorf("invalid task in the trash: %w", err)
+		}
+		contents.Entries = append(contents.Entries, entry)
+	}
+	return encode(contents)
+}
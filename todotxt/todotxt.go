@@ -0,0 +1,293 @@
+// Package todotxt converts tasks to and from the todo.txt format, see
+// https://github.com/todotxt/todo.txt
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"noted/task"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// due dates with a time of day keep it, which plain todo.txt dates cannot
+const dateTimeLayout = "2006-01-02T15:04"
+
+// keys noted reads from and writes to the key:value pairs of a line, any
+// other pair stays part of the task text
+const (
+	dueKey       = "due"
+	thresholdKey = "t"
+	statusKey    = "status"
+	priorityKey  = "pri"
+	idKey        = "id"
+)
+
+// reserved are the keys noted reads, a word of the task text looking like one
+// of their pairs is escaped with a backslash so it stays text
+var reserved = map[string]bool{dueKey: true, thresholdKey: true, statusKey: true, priorityKey: true, idKey: true}
+
+var priorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+// Format renders a task as a single todo.txt line. Details do not fit the
+// format and are left out; statuses other than done are kept in a status: pair.
+func Format(t task.Task) string {
+	parts := make([]string, 0)
+	finished := t.Status == task.Done || t.Status == task.Cancelled
+
+	if finished {
+		// the completion date must precede the creation date
+		completed := t.CreatedAt
+		if t.CompletedAt != nil {
+			completed = *t.CompletedAt
+		}
+		parts = append(parts, "x", completed.In(time.Local).Format(dateLayout))
+	} else if t.Priority != "" {
+		parts = append(parts, fmt.Sprintf("(%s)", t.Priority))
+	}
+	parts = append(parts, t.CreatedAt.In(time.Local).Format(dateLayout))
+
+	words := strings.Fields(t.Task)
+	for _, word := range words {
+		parts = append(parts, escape(word))
+	}
+	for _, project := range t.Projects {
+		if !contains(words, "+"+project) {
+			parts = append(parts, "+"+project)
+		}
+	}
+	for _, context := range t.Contexts {
+		if !contains(words, "@"+context) {
+			parts = append(parts, "@"+context)
+		}
+	}
+
+	if t.DueAt != nil {
+		parts = append(parts, pair(dueKey, formatDate(*t.DueAt)))
+	}
+	if t.ScheduledFor != nil {
+		parts = append(parts, pair(thresholdKey, formatDate(*t.ScheduledFor)))
+	}
+	if t.Status != task.ToDo && t.Status != task.Done {
+		parts = append(parts, pair(statusKey, strings.ToLower(t.Status.AsString())))
+	}
+	if finished && t.Priority != "" {
+		// by convention completed tasks move their priority into a pair
+		parts = append(parts, pair(priorityKey, t.Priority))
+	}
+	parts = append(parts, pair(idKey, identifier(t)))
+
+	return strings.Join(parts, " ")
+}
+
+// Parse reads a single todo.txt line. The value of an id: pair, if any, is
+// returned as the task's UID. Only lines with an id: pair, as exported by
+// noted, may have no text.
+func Parse(line string) (task.Task, error) {
+	var t task.Task
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return t, fmt.Errorf("empty line")
+	}
+
+	if words[0] == "x" {
+		t.Status = task.Done
+		words = words[1:]
+	}
+	if len(words) > 0 {
+		if match := priorityPattern.FindStringSubmatch(words[0]); match != nil {
+			t.Priority = match[1]
+			words = words[1:]
+		}
+	}
+
+	// only a finished task carries a completion date before its creation date
+	maxDates := 1
+	if t.Status == task.Done {
+		maxDates = 2
+	}
+	dates := make([]time.Time, 0, maxDates)
+	for len(words) > 0 && len(dates) < maxDates {
+		date, err := time.ParseInLocation(dateLayout, words[0], time.Local)
+		if err != nil {
+			break
+		}
+		dates = append(dates, date)
+		words = words[1:]
+	}
+	switch {
+	case len(dates) == 2:
+		t.CompletedAt, t.CreatedAt = &dates[0], dates[1]
+	case len(dates) == 1 && t.Status == task.Done:
+		t.CompletedAt = &dates[0]
+	case len(dates) == 1:
+		t.CreatedAt = dates[0]
+	}
+
+	text := make([]string, 0, len(words))
+	for _, word := range words {
+		key, value, isPair := strings.Cut(word, ":")
+		var err error
+		switch {
+		case isPair && key == dueKey:
+			t.DueAt, err = parseDate(value)
+		case isPair && key == thresholdKey:
+			t.ScheduledFor, err = parseDate(value)
+		case isPair && key == statusKey:
+			var status task.Status
+			// a finished line only ever marks the task as cancelled
+			if status, err = task.ParseStatus(value); err == nil && (t.Status != task.Done || status == task.Cancelled) {
+				t.Status = status
+			}
+		case isPair && key == priorityKey:
+			t.Priority = strings.ToUpper(value)
+		case isPair && key == idKey:
+			t.UID = value
+		default:
+			word = unescape(word)
+			if len(word) > 1 && word[0] == '+' {
+				t.Projects = append(t.Projects, word[1:])
+			} else if len(word) > 1 && word[0] == '@' {
+				t.Contexts = append(t.Contexts, word[1:])
+			}
+			text = append(text, word)
+		}
+		if err != nil {
+			return t, fmt.Errorf("invalid %s: %w", word, err)
+		}
+	}
+
+	t.Task = strings.Join(text, " ")
+	if t.Task == "" && t.UID == "" {
+		return t, fmt.Errorf("task has no text")
+	}
+	return t, nil
+}
+
+// Write renders tasks as a todo.txt file
+func Write(w io.Writer, tasks []task.Task) error {
+	for _, t := range tasks {
+		if _, err := fmt.Fprintln(w, Format(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import creates a task for every line of a todo.txt file, updating tasks
+// that were exported before instead of duplicating them. Lines without an
+// id: pair are always added as new tasks.
+func Import(r io.Reader) (task.ImportResult, error) {
+	importer, err := task.NewImporter()
+	if err != nil {
+		return task.ImportResult{}, err
+	}
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		parsed, err := Parse(scanner.Text())
+		if err != nil {
+			return importer.Result, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		_, err = importer.Import(parsed.UID, func(t *task.Task) error {
+			apply(parsed, t)
+			return nil
+		})
+		if err != nil {
+			return importer.Result, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return importer.Result, scanner.Err()
+}
+
+// apply copies everything a todo.txt line describes onto t
+func apply(parsed task.Task, t *task.Task) {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = parsed.CreatedAt
+	}
+	t.Task = parsed.Task
+	t.Priority = parsed.Priority
+	t.Projects = parsed.Projects
+	t.Contexts = parsed.Contexts
+	t.DueAt = parsed.DueAt
+	t.ScheduledFor = parsed.ScheduledFor
+	t.Status = parsed.Status
+	// the line only knows the day a task was completed on
+	if parsed.CompletedAt != nil && (t.CompletedAt == nil || !sameDay(*t.CompletedAt, *parsed.CompletedAt)) {
+		t.CompletedAt = parsed.CompletedAt
+	}
+}
+
+func identifier(t task.Task) string {
+	if t.UID != "" {
+		return t.UID
+	}
+	return t.Id
+}
+
+// escape adds a backslash to a word of the task text that would be read as a
+// pair, or that is an escaped pair already, so unescape can take one off
+func escape(word string) string {
+	if isPair(strings.TrimLeft(word, `\`)) {
+		return `\` + word
+	}
+	return word
+}
+
+func unescape(word string) string {
+	if strings.HasPrefix(word, `\`) && isPair(strings.TrimLeft(word, `\`)) {
+		return word[1:]
+	}
+	return word
+}
+
+func isPair(word string) bool {
+	key, _, found := strings.Cut(word, ":")
+	return found && reserved[key]
+}
+
+func pair(key string, value string) string {
+	return key + ":" + value
+}
+
+func formatDate(date time.Time) string {
+	date = date.In(time.Local)
+	if date.Hour() == 0 && date.Minute() == 0 {
+		return date.Format(dateLayout)
+	}
+	return date.Format(dateTimeLayout)
+}
+
+func parseDate(value string) (*time.Time, error) {
+	for _, layout := range []string{dateLayout, dateTimeLayout} {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &date, nil
+		}
+	}
+	return nil, fmt.Errorf("expected YYYY-MM-DD or YYYY-MM-DDTHH:MM")
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	return a.In(time.Local).Format(dateLayout) == b.In(time.Local).Format(dateLayout)
+}
+
+func contains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
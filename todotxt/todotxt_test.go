@@ -0,0 +1,129 @@
+package todotxt
+
+import (
+	"noted/task"
+	"slices"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int, hour int, minute int) *time.Time {
+	d := time.Date(year, month, day, hour, minute, 0, 0, time.Local)
+	return &d
+}
+
+func equalTimes(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := *date(2023, time.September, 1, 0, 0)
+	tests := []struct {
+		name string
+		task task.Task
+	}{
+		{"plain", task.Task{Task: "buy milk"}},
+		{"priority", task.Task{Task: "file taxes", Priority: "A"}},
+		{"projects and contexts", task.Task{Task: "call +garage about the car @phone", Projects: []string{"garage"}, Contexts: []string{"phone"}}},
+		{"due date", task.Task{Task: "pay rent", DueAt: date(2023, time.October, 1, 0, 0)}},
+		{"due time", task.Task{Task: "dentist", DueAt: date(2023, time.October, 3, 14, 30)}},
+		{"scheduled", task.Task{Task: "plant bulbs", ScheduledFor: date(2023, time.October, 15, 0, 0)}},
+		{"in progress", task.Task{Task: "write report", Status: task.InProgress}},
+		{"done", task.Task{Task: "mow lawn", Status: task.Done, CompletedAt: date(2023, time.September, 3, 0, 0)}},
+		{"done with priority", task.Task{Task: "renew passport", Status: task.Done, Priority: "B", CompletedAt: date(2023, time.September, 4, 0, 0)}},
+		{"cancelled", task.Task{Task: "book hotel", Status: task.Cancelled, CompletedAt: date(2023, time.September, 5, 0, 0)}},
+		{"empty title", task.Task{}},
+		{"reserved pairs in title", task.Task{Task: "set status:done and due:tomorrow in t:1 pri:A id:7"}},
+		{"escaped pairs in title", task.Task{Task: `literal \status:x and \\due:y`}},
+		{"other pairs in title", task.Task{Task: "meet at 10:30 re:budget", DueAt: date(2023, time.October, 2, 0, 0)}},
+		{"leading date in title", task.Task{Task: "2023-10-01 launch", Status: task.Done, CompletedAt: date(2023, time.September, 6, 0, 0)}},
+		{"leading priority in title", task.Task{Task: "(B) is not a priority"}},
+		{"imported uid", task.Task{Task: "from elsewhere", UID: "20230901-42@example.com"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := test.task
+			original.Id = "0f8fad5b-d9cb-469f-a165-70867728950e"
+			original.CreatedAt = created
+			line := Format(original)
+
+			parsed, err := Parse(line)
+			if err != nil {
+				t.Fatalf("%q: %s", line, err)
+			}
+			if parsed.Task != original.Task {
+				t.Errorf("%q: task is %q, want %q", line, parsed.Task, original.Task)
+			}
+			if parsed.Status != original.Status {
+				t.Errorf("%q: status is %s, want %s", line, parsed.Status.AsString(), original.Status.AsString())
+			}
+			if parsed.Priority != original.Priority {
+				t.Errorf("%q: priority is %q, want %q", line, parsed.Priority, original.Priority)
+			}
+			if !parsed.CreatedAt.Equal(original.CreatedAt) {
+				t.Errorf("%q: created at %s, want %s", line, parsed.CreatedAt, original.CreatedAt)
+			}
+			if !equalTimes(parsed.DueAt, original.DueAt) {
+				t.Errorf("%q: due at %v, want %v", line, parsed.DueAt, original.DueAt)
+			}
+			if !equalTimes(parsed.ScheduledFor, original.ScheduledFor) {
+				t.Errorf("%q: scheduled for %v, want %v", line, parsed.ScheduledFor, original.ScheduledFor)
+			}
+			if !equalTimes(parsed.CompletedAt, original.CompletedAt) {
+				t.Errorf("%q: completed at %v, want %v", line, parsed.CompletedAt, original.CompletedAt)
+			}
+			if parsed.UID != identifier(original) {
+				t.Errorf("%q: uid is %q, want %q", line, parsed.UID, identifier(original))
+			}
+			for _, project := range original.Projects {
+				if !slices.Contains(parsed.Projects, project) {
+					t.Errorf("%q: project %s is missing from %v", line, project, parsed.Projects)
+				}
+			}
+			for _, context := range original.Contexts {
+				if !slices.Contains(parsed.Contexts, context) {
+					t.Errorf("%q: context %s is missing from %v", line, context, parsed.Contexts)
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line    string
+		task    string
+		status  task.Status
+		invalid bool
+	}{
+		{line: "(A) 2023-09-01 call mom +family @phone", task: "call mom +family @phone"},
+		{line: "x 2023-09-02 2023-09-01 call mom status:cancelled", task: "call mom", status: task.Cancelled},
+		{line: "x 2023-09-02 call mom status:in-progress", task: "call mom", status: task.Done},
+		{line: "2023-09-01 call mom status:pause", task: "call mom", status: task.Paused},
+		{line: "2023-09-01 call mom due:soon", invalid: true},
+		{line: "2023-09-01 call mom status:someday", invalid: true},
+		{line: "2023-09-01 due:2023-09-10", invalid: true},
+		{line: "   ", invalid: true},
+	}
+
+	for _, test := range tests {
+		parsed, err := Parse(test.line)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%q parsed as %+v, want an error", test.line, parsed)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+		if parsed.Task != test.task || parsed.Status != test.status {
+			t.Errorf("%q parsed as %q with status %s, want %q with status %s", test.line, parsed.Task, parsed.Status.AsString(), test.task, test.status.AsString())
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	config "noted/config"
+	"noted/logging"
+	"noted/storage"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Prefix is the directory inside the storage directory the trash is kept in
+const Prefix = "trash"
+
+// Name is the trash file, relative to the storage directory
+const Name = Prefix + "/trash.yaml"
+
+var ErrUnknownItem = errors.New("not in the trash")
+
+// Item is a deleted task or journal entry
+type Item struct {
+	Id    string
+	Kind  string
+	Title string
+	// File is the file the item was deleted from, relative to the storage
+	// directory
+	File      string
+	DeletedAt time.Time `yaml:"deleted_at"`
+	// Data is the item as its file held it, such as a journal line
+	Data string
+}
+
+// Path is the file the item was deleted from
+func (i Item) Path() string {
+	return path.Join(storageDir(), i.File)
+}
+
+type trashFile struct {
+	Items []Item
+}
+
+// Restorer puts an item back into the file it was deleted from, without
+// announcing the change as Restore does
+type Restorer func(item Item) error
+
+var restorers = make(map[string]Restorer)
+
+// RegisterKind makes items of kind restorable
+func RegisterKind(kind string, restore Restorer) {
+	restorers[kind] = restore
+}
+
+func storageDir() string {
+	return viper.GetString(config.ConfigStorageDir)
+}
+
+func File() string {
+	return path.Join(storageDir(), Name)
+}
+
+// Add puts an item deleted from file into the trash, purging the items past
+// their retention. The change is left to the deletion to announce.
+func Add(kind string, title string, file string, data string) (Item, error) {
+	if filepath.IsAbs(file) {
+		if relative, err := filepath.Rel(storageDir(), file); err == nil {
+			file = filepath.ToSlash(relative)
+		}
+	}
+	item := Item{
+		Id:        uuid.NewString(),
+		Kind:      kind,
+		Title:     title,
+		File:      file,
+		DeletedAt: time.Now(),
+		Data:      data,
+	}
+
+	contents, err := load()
+	if err != nil {
+		return item, err
+	}
+	purge(&contents, time.Now())
+	contents.Items = append(contents.Items, item)
+	return item, save(contents)
+}
+
+// List returns the items in the trash, most recently deleted first
+func List() ([]Item, error) {
+	contents, err := load()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(contents.Items, func(a Item, b Item) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return contents.Items, nil
+}
+
+// Find looks an item up by its id or a unique prefix of it
+func Find(id string) (Item, error) {
+	items, err := List()
+	if err != nil {
+		return Item{}, err
+	}
+
+	var matched []Item
+	for _, item := range items {
+		if item.Id == id {
+			return item, nil
+		}
+		if strings.HasPrefix(item.Id, id) {
+			matched = append(matched, item)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return Item{}, fmt.Errorf("%s: %w", id, ErrUnknownItem)
+	case 1:
+		return matched[0], nil
+	default:
+		return Item{}, fmt.Errorf("%s matches %d items in the trash", id, len(matched))
+	}
+}
+
+// Restore puts the item with the given id back where it was deleted from and
+// takes it out of the trash
+func Restore(id string) (Item, error) {
+	item, err := Find(id)
+	if err != nil {
+		return item, err
+	}
+	restore, ok := restorers[item.Kind]
+	if !ok {
+		return item, fmt.Errorf("cannot restore items of kind %q", item.Kind)
+	}
+	if err = restore(item); err != nil {
+		return item, err
+	}
+
+	contents, err := load()
+	if err != nil {
+		return item, err
+	}
+	contents.Items = slices.DeleteFunc(contents.Items, func(other Item) bool {
+		return other.Id == item.Id
+	})
+	if err = save(contents); err != nil {
+		return item, err
+	}
+
+	storage.Changed(fmt.Sprintf("restore %s %q from trash", item.Kind, item.Title))
+	return item, nil
+}
+
+// Empty removes every item from the trash and returns how many there were
+func Empty() (int, error) {
+	contents, err := load()
+	if err != nil || len(contents.Items) == 0 {
+		return 0, err
+	}
+	emptied := len(contents.Items)
+	if err = save(trashFile{}); err != nil {
+		return 0, err
+	}
+	storage.Changed("empty trash")
+	return emptied, nil
+}
+
+// Purge removes the items deleted longer than trash.retention ago and
+// returns how many there were
+func Purge() (int, error) {
+	contents, err := load()
+	if err != nil {
+		return 0, err
+	}
+	purged := purge(&contents, time.Now())
+	if purged == 0 {
+		return 0, nil
+	}
+	if err = save(contents); err != nil {
+		return 0, err
+	}
+	storage.Changed(fmt.Sprintf("purge %d items from trash", purged))
+	return purged, nil
+}
+
+// MergeFiles merges the contents of the trash file changed on two sides since
+// base. Items deleted on either side are kept, items restored, emptied or
+// purged on either side are dropped.
+func MergeFiles(base []byte, ours []byte, theirs []byte) ([]byte, error) {
+	versions := make([]trashFile, 3)
+	for i, data := range [][]byte{base, ours, theirs} {
+		if err := yaml.Unmarshal(data, &versions[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := func(contents trashFile) map[string]bool {
+		found := make(map[string]bool, len(contents.Items))
+		for _, item := range contents.Items {
+			found[item.Id] = true
+		}
+		return found
+	}
+	inBase, inOurs, inTheirs := ids(versions[0]), ids(versions[1]), ids(versions[2])
+
+	var merged trashFile
+	seen := make(map[string]bool)
+	for _, item := range append(versions[1].Items, versions[2].Items...) {
+		taken := inBase[item.Id] && (!inOurs[item.Id] || !inTheirs[item.Id])
+		if taken || seen[item.Id] {
+			continue
+		}
+		seen[item.Id] = true
+		merged.Items = append(merged.Items, item)
+	}
+	slices.SortStableFunc(merged.Items, func(a Item, b Item) int {
+		return a.DeletedAt.Compare(b.DeletedAt)
+	})
+	return yaml.Marshal(merged)
+}
+
+// AutoPurge is a storage.ChangeHook dropping the items past their retention.
+// It leaves the trash file for the hooks after it to commit along with the
+// change, so it is registered before them.
+func AutoPurge(description string) {
+	contents, err := load()
+	if err != nil {
+		logging.Logger.Warn("failed to read the trash", zap.Error(err))
+		return
+	}
+	if purge(&contents, time.Now()) == 0 {
+		return
+	}
+	if err = save(contents); err != nil {
+		logging.Logger.Warn("failed to purge the trash", zap.Error(err))
+	}
+}
+
+// purge drops the items past their retention, a retention of 0 keeping them
+// forever
+func purge(contents *trashFile, now time.Time) int {
+	retention := viper.GetDuration(config.ConfigTrashRetention)
+	if retention <= 0 {
+		return 0
+	}
+	before := len(contents.Items)
+	contents.Items = slices.DeleteFunc(contents.Items, func(item Item) bool {
+		return now.Sub(item.DeletedAt) > retention
+	})
+	return before - len(contents.Items)
+}
+
+func load() (trashFile, error) {
+	var contents trashFile
+	data, err := storage.ReadFile(File())
+	if errors.Is(err, os.ErrNotExist) {
+		return contents, nil
+	} else if err != nil {
+		return contents, err
+	}
+	if err = yaml.Unmarshal(data, &contents); err != nil {
+		return contents, storage.YAMLError(File(), err)
+	}
+	return contents, nil
+}
+
+func save(contents trashFile) error {
+	if err := os.MkdirAll(path.Dir(File()), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(contents)
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(File(), data)
+}
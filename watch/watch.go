@@ -0,0 +1,62 @@
+package watch
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"noted/logging"
+	"time"
+)
+
+// ChangedMsg is sent to the program whenever files in a watched directory change
+type ChangedMsg struct {
+	Dir string
+}
+
+// editors and sync tools tend to write a file several times in a row, so
+// events are coalesced until the directory has been quiet for this long
+const settleDelay = 200 * time.Millisecond
+
+// Directory watches dir and sends a ChangedMsg to program once changes settle.
+// The returned function stops the watcher.
+func Directory(program *tea.Program, dir string) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logging.Logger.Error("failed to create file watcher", zap.Error(err))
+		return nil, err
+	}
+
+	if err = watcher.Add(dir); err != nil {
+		logging.Logger.Error("failed to watch directory", zap.String("directory", dir), zap.Error(err))
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(settleDelay, func() {
+					program.Send(ChangedMsg{Dir: dir})
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logging.Logger.Error("file watcher failed", zap.String("directory", dir), zap.Error(err))
+			}
+		}
+	}()
+
+	return watcher.Close, nil
+}
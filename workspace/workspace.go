@@ -0,0 +1,174 @@
+// Package workspace keeps separate note stores, such as one for work and one
+// for home, each in its own storage directory:
+//
+//	workspace: work
+//	workspaces:
+//	  work:
+//	    storageDir: ~/notes/work
+//	  home:
+//	    storageDir: ~/notes/home
+//
+// The top level storageDir is the default workspace.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"noted/config"
+	"noted/crypt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+const Default = "default"
+
+var (
+	ErrUnknownWorkspace = errors.New("unknown workspace")
+	ErrInvalidName      = errors.New("invalid workspace name")
+	ErrWorkspaceExists  = errors.New("workspace already exists")
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type Workspace struct {
+	Name       string
+	StorageDir string
+}
+
+// base is the storage directory of the default workspace, remembered as
+// activating a workspace replaces storageDir
+var base string
+
+func defaultDir() string {
+	if base == "" {
+		base = viper.GetString(noted.ConfigStorageDir)
+	}
+	return base
+}
+
+// List returns the default workspace followed by the configured ones by name
+func List() []Workspace {
+	workspaces := []Workspace{{Name: Default, StorageDir: defaultDir()}}
+
+	names := make([]string, 0)
+	// viper lowercases keys, which is why names are lowercase
+	for name := range viper.GetStringMap(noted.ConfigWorkspaces) {
+		if name != Default {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		dir, err := homedir.Expand(viper.GetString(fmt.Sprintf("%s.%s.%s", noted.ConfigWorkspaces, name, noted.ConfigStorageDir)))
+		if err != nil || dir == "" {
+			continue
+		}
+		workspaces = append(workspaces, Workspace{Name: name, StorageDir: dir})
+	}
+	return workspaces
+}
+
+func Get(name string) (Workspace, error) {
+	if name == "" {
+		name = Default
+	}
+	for _, workspace := range List() {
+		if workspace.Name == name {
+			return workspace, nil
+		}
+	}
+	return Workspace{}, fmt.Errorf("%w: %s", ErrUnknownWorkspace, name)
+}
+
+// Current is the name of the active workspace
+func Current() string {
+	if name := viper.GetString(noted.ConfigWorkspace); name != "" {
+		return name
+	}
+	return Default
+}
+
+// Activate points storageDir at the workspace, so everything that follows
+// reads and writes its notes
+func Activate(name string) error {
+	workspace, err := Get(name)
+	if err != nil {
+		return err
+	}
+	defaultDir()
+	viper.Set(noted.ConfigStorageDir, workspace.StorageDir)
+	viper.Set(noted.ConfigWorkspace, workspace.Name)
+	crypt.Forget()
+	return nil
+}
+
+// Each activates every workspace in turn, returning to the current one
+// afterwards. Workspaces without a storage directory yet are skipped.
+func Each(fn func(Workspace) error) error {
+	current := Current()
+	defer Activate(current)
+
+	for _, workspace := range List() {
+		if _, err := os.Stat(workspace.StorageDir); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := Activate(workspace.Name); err != nil {
+			return err
+		}
+		if err := fn(workspace); err != nil {
+			return fmt.Errorf("workspace %s: %w", workspace.Name, err)
+		}
+	}
+	return nil
+}
+
+// Create adds a workspace to the configuration file and creates its storage
+// directory. Without a directory it goes next to the default one, so
+// ~/.noted gets a sibling ~/.noted-work.
+func Create(name string, dir string) (Workspace, error) {
+	if !validName.MatchString(name) {
+		return Workspace{}, fmt.Errorf("%w %q, use lowercase letters, digits, - and _", ErrInvalidName, name)
+	}
+	if _, err := Get(name); err == nil {
+		return Workspace{}, fmt.Errorf("%w: %s", ErrWorkspaceExists, name)
+	}
+	if err := noted.Writable(noted.File()); err != nil {
+		return Workspace{}, err
+	}
+
+	if dir == "" {
+		dir = path.Join(path.Dir(defaultDir()), path.Base(defaultDir())+"-"+name)
+	}
+	dir, err := homedir.Expand(dir)
+	if err == nil {
+		dir, err = filepath.Abs(dir)
+	}
+	if err != nil {
+		return Workspace{}, err
+	}
+	for _, prefix := range []string{viper.GetString(noted.ConfigTaskPrefix), viper.GetString(noted.ConfigJournalPrefix)} {
+		if err = os.MkdirAll(path.Join(dir, prefix), 0755); err != nil {
+			return Workspace{}, err
+		}
+	}
+
+	key := fmt.Sprintf("%s.%s.%s", noted.ConfigWorkspaces, name, noted.ConfigStorageDir)
+	if err = noted.WriteValue(noted.File(), key, dir); err != nil {
+		return Workspace{}, err
+	}
+	return Workspace{Name: name, StorageDir: dir}, nil
+}
+
+// Use makes the workspace the one noted starts in
+func Use(name string) error {
+	if _, err := Get(name); err != nil {
+		return err
+	}
+	return noted.WriteValue(noted.File(), noted.ConfigWorkspace, name)
+}